@@ -3,11 +3,14 @@ package ast
 import (
 	"blank/token"
 	"bytes"
+	"strings"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Pos // position of the first character of the node
+	End() token.Pos // position immediately after the node
 }
 
 type Statement interface {
@@ -21,18 +24,29 @@ type Expression interface {
 }
 
 type VarStatement struct {
-	Token token.Token // token.VAR
-	Name  *Identifier
-	Value Expression
+	Token    token.Token // token.VAR or token.CONST
+	Name     *Identifier
+	Value    Expression
+	Constant bool          // true for const declarations, which cannot be reassigned
+	Doc      *CommentGroup // the doc comment right before the declaration, or nil
 }
 
 func (ls *VarStatement) statementNode()       {}
 func (ls *VarStatement) TokenLiteral() string { return ls.Token.Literal }
+func (vs *VarStatement) Pos() token.Pos       { return vs.Token.Pos }
+func (vs *VarStatement) End() token.Pos {
+	if vs.Value != nil {
+		return vs.Value.End()
+	}
+	return vs.Name.End()
+}
 func (vs *VarStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(vs.TokenLiteral() + " ")
-	out.WriteString(vs.Name.String())
+	if vs.Name != nil {
+		out.WriteString(vs.Name.String())
+	}
 	out.WriteString(" = ")
 
 	if vs.Value != nil {
@@ -45,14 +59,27 @@ func (vs *VarStatement) String() string {
 type Identifier struct {
 	Token token.Token // token.IDENT
 	Value string
+
+	// Resolution is set by the resolver to where the name is declared,
+	// it stays nil for declarations and unresolved names
+	Resolution *Resolution
+}
+
+// Resolution locates the declaration an identifier refers to
+type Resolution struct {
+	Depth int `json:"depth"` // number of scopes between the use and the declaration
+	Slot  int `json:"slot"`  // index of the declaration within its scope
 }
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 func (i *Identifier) String() string       { return i.Value }
+func (i *Identifier) Pos() token.Pos       { return i.Token.Pos }
+func (i *Identifier) End() token.Pos       { return i.Token.End }
 
 type Program struct {
 	Statements []Statement
+	Comments   []*CommentGroup // every comment of the source, in order
 }
 
 func (p *Program) TokenLiteral() string {
@@ -63,6 +90,20 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Pos {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Pos{}
+}
+
+func (p *Program) End() token.Pos {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Pos{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -80,6 +121,13 @@ type ReturnStatement struct {
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() token.Pos       { return rs.Token.Pos }
+func (rs *ReturnStatement) End() token.Pos {
+	if rs.ReturnValue != nil {
+		return rs.ReturnValue.End()
+	}
+	return rs.Token.End
+}
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(rs.TokenLiteral() + " ")
@@ -97,6 +145,13 @@ type ExpressionStatement struct {
 
 func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Pos() token.Pos       { return es.Token.Pos }
+func (es *ExpressionStatement) End() token.Pos {
+	if es.Expression != nil {
+		return es.Expression.End()
+	}
+	return es.Token.End
+}
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -111,6 +166,8 @@ type IntegerLiteral struct {
 
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+func (il *IntegerLiteral) Pos() token.Pos       { return il.Token.Pos }
+func (il *IntegerLiteral) End() token.Pos       { return il.Token.End }
 func (il *IntegerLiteral) String() string {
 	if il != nil {
 		return il.Token.Literal
@@ -125,11 +182,20 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Pos       { return pe.Token.Pos }
+func (pe *PrefixExpression) End() token.Pos {
+	if pe.Right != nil {
+		return pe.Right.End()
+	}
+	return pe.Token.End
+}
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 	if pe != nil {
 		out.WriteString(pe.Token.Literal)
-		out.WriteString(pe.Right.String())
+		if pe.Right != nil {
+			out.WriteString(pe.Right.String())
+		}
 	}
 	return out.String()
 }
@@ -142,12 +208,28 @@ type InfixExpression struct {
 
 func (ip *InfixExpression) expressionNode()      {}
 func (ip *InfixExpression) TokenLiteral() string { return ip.Operator.Literal }
+func (ip *InfixExpression) Pos() token.Pos {
+	if ip.Left != nil {
+		return ip.Left.Pos()
+	}
+	return ip.Operator.Pos
+}
+func (ip *InfixExpression) End() token.Pos {
+	if ip.Right != nil {
+		return ip.Right.End()
+	}
+	return ip.Operator.End
+}
 func (ip *InfixExpression) String() string {
 	var out bytes.Buffer
 	if ip != nil {
-		out.WriteString(ip.Left.String())
+		if ip.Left != nil {
+			out.WriteString(ip.Left.String())
+		}
 		out.WriteString(" " + ip.TokenLiteral() + " ")
-		out.WriteString(ip.Right.String())
+		if ip.Right != nil {
+			out.WriteString(ip.Right.String())
+		}
 	}
 	return out.String()
 }
@@ -160,3 +242,196 @@ type BooleanExpression struct {
 func (b *BooleanExpression) expressionNode()      {}
 func (b *BooleanExpression) TokenLiteral() string { return b.Token.Literal }
 func (b *BooleanExpression) String() string       { return b.Token.Literal }
+func (b *BooleanExpression) Pos() token.Pos       { return b.Token.Pos }
+func (b *BooleanExpression) End() token.Pos       { return b.Token.End }
+
+type BlockStatement struct {
+	Token      token.Token // the '{' token
+	Statements []Statement
+	Rbrace     token.Token // the '}' token
+}
+
+func (bs *BlockStatement) statementNode()       {}
+func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Pos       { return bs.Token.Pos }
+func (bs *BlockStatement) End() token.Pos {
+	if bs.Rbrace.Type == token.RBRACE {
+		return bs.Rbrace.End
+	}
+	if len(bs.Statements) > 0 {
+		return bs.Statements[len(bs.Statements)-1].End()
+	}
+	return bs.Token.End
+}
+func (bs *BlockStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("{ ")
+	for _, s := range bs.Statements {
+		out.WriteString(s.String())
+	}
+	out.WriteString(" }")
+	return out.String()
+}
+
+type IfExpression struct {
+	Token       token.Token // the 'if' token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement
+}
+
+func (ie *IfExpression) expressionNode()      {}
+func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Pos       { return ie.Token.Pos }
+func (ie *IfExpression) End() token.Pos {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	return ie.Consequence.End()
+}
+func (ie *IfExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("if (")
+	if ie.Condition != nil {
+		out.WriteString(ie.Condition.String())
+	}
+	out.WriteString(") ")
+	if ie.Consequence != nil {
+		out.WriteString(ie.Consequence.String())
+	}
+	if ie.Alternative != nil {
+		out.WriteString(" else ")
+		out.WriteString(ie.Alternative.String())
+	}
+	return out.String()
+}
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode()      {}
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Pos       { return sl.Token.Pos }
+func (sl *StringLiteral) End() token.Pos       { return sl.Token.End }
+func (sl *StringLiteral) String() string {
+	var out bytes.Buffer
+	out.WriteByte('"')
+	for _, ch := range sl.Value {
+		switch ch {
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '"', '\\':
+			out.WriteRune('\\')
+			out.WriteRune(ch)
+		default:
+			out.WriteRune(ch)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+// Comment is a single // or /* */ comment
+type Comment struct {
+	Token token.Token // token.COMMENT, the literal is the whole comment
+}
+
+func (c *Comment) Pos() token.Pos { return c.Token.Pos }
+func (c *Comment) End() token.Pos { return c.Token.End }
+
+// IsDoc reports whether the comment is a doc comment: /// or /** */
+func (c *Comment) IsDoc() bool {
+	text := c.Token.Literal
+	switch {
+	case strings.HasPrefix(text, "///"):
+		return !strings.HasPrefix(text, "////")
+	case strings.HasPrefix(text, "/**"):
+		return text != "/**/" && !strings.HasPrefix(text, "/***")
+	}
+	return false
+}
+
+// CommentGroup is a sequence of comments on consecutive lines, with no token between them
+type CommentGroup struct {
+	List []*Comment
+}
+
+func (g *CommentGroup) Pos() token.Pos { return g.List[0].Pos() }
+func (g *CommentGroup) End() token.Pos { return g.List[len(g.List)-1].End() }
+
+// Text returns the text of the comments without the comment markers, one line per line of comment
+func (g *CommentGroup) Text() string {
+	if g == nil {
+		return ""
+	}
+	var lines []string
+	for _, c := range g.List {
+		text := c.Token.Literal
+		switch {
+		case strings.HasPrefix(text, "//"):
+			lines = append(lines, strings.TrimPrefix(strings.TrimLeft(text, "/"), " "))
+		default:
+			text = strings.TrimSuffix(strings.TrimLeft(strings.TrimPrefix(text, "/"), "*"), "*/")
+			for _, line := range strings.Split(text, "\n") {
+				line = strings.TrimSpace(line)
+				line = strings.TrimPrefix(strings.TrimPrefix(line, "*"), " ")
+				lines = append(lines, strings.TrimRight(line, " \t\r"))
+			}
+		}
+	}
+	// drop the blank lines around the text, e.g. from /** on its own line
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+type CallExpression struct {
+	Token     token.Token // the '(' token
+	Function  Expression  // identifier or any expression evaluating to a function
+	Arguments []Expression
+	Rparen    token.Token // the ')' token
+}
+
+func (ce *CallExpression) expressionNode()      {}
+func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Pos {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Pos
+}
+func (ce *CallExpression) End() token.Pos {
+	if ce.Rparen.Type == token.RPAREN {
+		return ce.Rparen.End
+	}
+	if len(ce.Arguments) > 0 && ce.Arguments[len(ce.Arguments)-1] != nil {
+		return ce.Arguments[len(ce.Arguments)-1].End()
+	}
+	return ce.Token.End
+}
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+	args := []string{}
+	for _, a := range ce.Arguments {
+		if a != nil {
+			args = append(args, a.String())
+		}
+	}
+	if ce.Function != nil {
+		out.WriteString(ce.Function.String())
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+	return out.String()
+}
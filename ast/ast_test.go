@@ -25,3 +25,27 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+// TestStringMissingNodes renders nodes with missing children, as in the partial
+// trees the parser returns for invalid input
+func TestStringMissingNodes(t *testing.T) {
+	minus := token.Token{Type: token.MINUS, Literal: "-"}
+	tests := []struct {
+		node     Node
+		expected string
+	}{
+		{&PrefixExpression{Token: minus}, "-"},
+		{&InfixExpression{Operator: minus}, " - "},
+		{&IfExpression{Token: token.Token{Type: token.IF, Literal: "if"}}, "if () "},
+		{&VarStatement{Token: token.Token{Type: token.VAR, Literal: "var"}}, "var  = ;"},
+		{&ReturnStatement{Token: token.Token{Type: token.RETURN, Literal: "return"}}, "return ;"},
+		{&ExpressionStatement{}, ""},
+		{&CallExpression{}, "()"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.node.String(); got != tt.expected {
+			t.Errorf("%T.String() wrong. expected=%q, got=%q", tt.node, tt.expected, got)
+		}
+	}
+}
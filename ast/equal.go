@@ -0,0 +1,298 @@
+package ast
+
+import (
+	"blank/token"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"reflect"
+)
+
+// EqualOptions controls what Equal and Hash compare
+type EqualOptions uint
+
+const (
+	// IgnorePositions compares the trees wherever their tokens are in the source
+	IgnorePositions EqualOptions = 1 << iota
+	// IgnoreComments skips the comments of programs and the doc comments of declarations
+	IgnoreComments
+	// IgnoreResolution skips the scope annotations the resolver puts on identifiers
+	IgnoreResolution
+)
+
+// Equal reports whether the trees of a and b are the same: nodes of the same kinds,
+// with the same tokens, values and children. Nil nodes are only equal to nil nodes
+func Equal(a, b Node, opts EqualOptions) bool {
+	if isNil(a) || isNil(b) {
+		return isNil(a) && isNil(b)
+	}
+
+	switch a := a.(type) {
+	// Statements
+	case *Program:
+		b, ok := b.(*Program)
+		return ok && equalStatements(a.Statements, b.Statements, opts) &&
+			(opts&IgnoreComments != 0 || equalCommentGroups(a.Comments, b.Comments, opts))
+	case *VarStatement:
+		b, ok := b.(*VarStatement)
+		return ok && equalTokens(a.Token, b.Token, opts) && Equal(a.Name, b.Name, opts) &&
+			Equal(a.Value, b.Value, opts) && a.Constant == b.Constant &&
+			(opts&IgnoreComments != 0 || equalCommentGroup(a.Doc, b.Doc, opts))
+	case *ReturnStatement:
+		b, ok := b.(*ReturnStatement)
+		return ok && equalTokens(a.Token, b.Token, opts) && Equal(a.ReturnValue, b.ReturnValue, opts)
+	case *ExpressionStatement:
+		b, ok := b.(*ExpressionStatement)
+		return ok && equalTokens(a.Token, b.Token, opts) && Equal(a.Expression, b.Expression, opts)
+	case *BlockStatement:
+		b, ok := b.(*BlockStatement)
+		return ok && equalTokens(a.Token, b.Token, opts) && equalStatements(a.Statements, b.Statements, opts) &&
+			equalTokens(a.Rbrace, b.Rbrace, opts)
+
+	// Expressions
+	case *Identifier:
+		b, ok := b.(*Identifier)
+		return ok && equalTokens(a.Token, b.Token, opts) && a.Value == b.Value &&
+			(opts&IgnoreResolution != 0 || equalResolutions(a.Resolution, b.Resolution))
+	case *IntegerLiteral:
+		b, ok := b.(*IntegerLiteral)
+		return ok && equalTokens(a.Token, b.Token, opts) && a.Value == b.Value
+	case *StringLiteral:
+		b, ok := b.(*StringLiteral)
+		return ok && equalTokens(a.Token, b.Token, opts) && a.Value == b.Value
+	case *BooleanExpression:
+		b, ok := b.(*BooleanExpression)
+		return ok && equalTokens(a.Token, b.Token, opts) && a.Value == b.Value
+	case *PrefixExpression:
+		b, ok := b.(*PrefixExpression)
+		return ok && equalTokens(a.Token, b.Token, opts) && Equal(a.Right, b.Right, opts)
+	case *InfixExpression:
+		b, ok := b.(*InfixExpression)
+		return ok && equalTokens(a.Operator, b.Operator, opts) && Equal(a.Left, b.Left, opts) &&
+			Equal(a.Right, b.Right, opts)
+	case *IfExpression:
+		b, ok := b.(*IfExpression)
+		return ok && equalTokens(a.Token, b.Token, opts) && Equal(a.Condition, b.Condition, opts) &&
+			Equal(a.Consequence, b.Consequence, opts) && Equal(a.Alternative, b.Alternative, opts)
+	case *CallExpression:
+		b, ok := b.(*CallExpression)
+		if !ok || !equalTokens(a.Token, b.Token, opts) || !Equal(a.Function, b.Function, opts) ||
+			len(a.Arguments) != len(b.Arguments) || !equalTokens(a.Rparen, b.Rparen, opts) {
+			return false
+		}
+		for i := range a.Arguments {
+			if !Equal(a.Arguments[i], b.Arguments[i], opts) {
+				return false
+			}
+		}
+		return true
+	}
+	panic(fmt.Sprintf("ast.Equal: unexpected node type %T", a))
+}
+
+// isNil reports whether n is nil or a nil pointer, as a missing child may be either
+func isNil(n Node) bool {
+	return n == nil || reflect.ValueOf(n).IsNil()
+}
+
+func equalTokens(a, b token.Token, opts EqualOptions) bool {
+	if opts&IgnorePositions != 0 {
+		return a.Type == b.Type && a.Literal == b.Literal
+	}
+	return a == b
+}
+
+func equalStatements(a, b []Statement, opts EqualOptions) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i], opts) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalResolutions(a, b *Resolution) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalCommentGroups(a, b []*CommentGroup, opts EqualOptions) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equalCommentGroup(a[i], b[i], opts) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalCommentGroup(a, b *CommentGroup, opts EqualOptions) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if len(a.List) != len(b.List) {
+		return false
+	}
+	for i := range a.List {
+		if !equalTokens(a.List[i].Token, b.List[i].Token, opts) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns a structural hash of the tree of node: trees that are Equal with
+// opts have the same hash. It does not depend on the process, so it can be stored
+func Hash(node Node, opts EqualOptions) uint64 {
+	h := &hasher{hash: fnv.New64a(), opts: opts}
+	h.node(node)
+	return h.hash.Sum64()
+}
+
+type hasher struct {
+	hash hash.Hash64
+	opts EqualOptions
+}
+
+func (h *hasher) node(n Node) {
+	if isNil(n) {
+		h.int(0)
+		return
+	}
+	h.string(reflect.TypeOf(n).Elem().Name())
+
+	switch n := n.(type) {
+	// Statements
+	case *Program:
+		h.statements(n.Statements)
+		if h.opts&IgnoreComments == 0 {
+			h.int(int64(len(n.Comments)))
+			for _, g := range n.Comments {
+				h.commentGroup(g)
+			}
+		}
+	case *VarStatement:
+		h.token(n.Token)
+		h.node(n.Name)
+		h.node(n.Value)
+		h.bool(n.Constant)
+		if h.opts&IgnoreComments == 0 {
+			h.commentGroup(n.Doc)
+		}
+	case *ReturnStatement:
+		h.token(n.Token)
+		h.node(n.ReturnValue)
+	case *ExpressionStatement:
+		h.token(n.Token)
+		h.node(n.Expression)
+	case *BlockStatement:
+		h.token(n.Token)
+		h.statements(n.Statements)
+		h.token(n.Rbrace)
+
+	// Expressions
+	case *Identifier:
+		h.token(n.Token)
+		h.string(n.Value)
+		if h.opts&IgnoreResolution == 0 {
+			h.bool(n.Resolution != nil)
+			if n.Resolution != nil {
+				h.int(int64(n.Resolution.Depth))
+				h.int(int64(n.Resolution.Slot))
+			}
+		}
+	case *IntegerLiteral:
+		h.token(n.Token)
+		h.int(n.Value)
+	case *StringLiteral:
+		h.token(n.Token)
+		h.string(n.Value)
+	case *BooleanExpression:
+		h.token(n.Token)
+		h.bool(n.Value)
+	case *PrefixExpression:
+		h.token(n.Token)
+		h.node(n.Right)
+	case *InfixExpression:
+		h.token(n.Operator)
+		h.node(n.Left)
+		h.node(n.Right)
+	case *IfExpression:
+		h.token(n.Token)
+		h.node(n.Condition)
+		h.node(n.Consequence)
+		h.node(n.Alternative)
+	case *CallExpression:
+		h.token(n.Token)
+		h.node(n.Function)
+		h.int(int64(len(n.Arguments)))
+		for _, arg := range n.Arguments {
+			h.node(arg)
+		}
+		h.token(n.Rparen)
+	default:
+		panic(fmt.Sprintf("ast.Hash: unexpected node type %T", n))
+	}
+}
+
+func (h *hasher) statements(statements []Statement) {
+	h.int(int64(len(statements)))
+	for _, s := range statements {
+		h.node(s)
+	}
+}
+
+func (h *hasher) commentGroup(g *CommentGroup) {
+	if g == nil {
+		h.int(0)
+		return
+	}
+	h.int(int64(len(g.List)))
+	for _, c := range g.List {
+		h.token(c.Token)
+	}
+}
+
+func (h *hasher) token(t token.Token) {
+	h.string(string(t.Type))
+	h.string(t.Literal)
+	if h.opts&IgnorePositions == 0 {
+		h.pos(t.Pos)
+		h.pos(t.End)
+	}
+}
+
+func (h *hasher) pos(p token.Pos) {
+	h.string(p.Filename)
+	h.int(int64(p.Line))
+	h.int(int64(p.Column))
+	h.int(int64(p.Offset))
+}
+
+// string writes s with its length first, so that consecutive strings cannot run into each other
+func (h *hasher) string(s string) {
+	h.int(int64(len(s)))
+	h.hash.Write([]byte(s))
+}
+
+func (h *hasher) int(i int64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(i))
+	h.hash.Write(buf[:])
+}
+
+func (h *hasher) bool(b bool) {
+	if b {
+		h.int(1)
+	} else {
+		h.int(0)
+	}
+}
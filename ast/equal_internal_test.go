@@ -0,0 +1,21 @@
+package ast
+
+import "testing"
+
+// TestEqualEveryKind compares empty nodes of every kind in kinds, so that Equal
+// and Hash support any node type added there and tell kinds apart
+func TestEqualEveryKind(t *testing.T) {
+	hashes := map[uint64]string{}
+	for kind, newNode := range kinds {
+		for other, newOther := range kinds {
+			if got := Equal(newNode(), newOther(), 0); got != (kind == other) {
+				t.Errorf("Equal(%s, %s) wrong. got=%t", kind, other, got)
+			}
+		}
+		h := Hash(newNode(), 0)
+		if prev, ok := hashes[h]; ok {
+			t.Errorf("%s and %s have the same hash", kind, prev)
+		}
+		hashes[h] = kind
+	}
+}
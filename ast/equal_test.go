@@ -0,0 +1,136 @@
+package ast_test
+
+import (
+	"blank/ast"
+	"blank/token"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		opts     ast.EqualOptions
+		expected bool
+	}{
+		{"var x = 1 + 2;", "var x = 1 + 2;", 0, true},
+		{"var x = 1 + 2;", "var x = 1 + 2;", ast.IgnorePositions, true},
+		{"var x = 1 + 2;", "var x =  1+2;", 0, false},
+		{"var x = 1 + 2;", "var x =  1+2;", ast.IgnorePositions, true},
+		{"var x = 1 + 2;", "var x = 1 - 2;", ast.IgnorePositions, false},
+		{"var x = 1 + 2;", "const x = 1 + 2;", ast.IgnorePositions, false},
+		{"var x = 1 + 2;", "var y = 1 + 2;", ast.IgnorePositions, false},
+		{"var x = 1 + 2;", "var x = 1 + 2; x;", ast.IgnorePositions, false},
+		{"(1 + 2) * 3", "1 + 2 * 3", ast.IgnorePositions, false},
+		{"((1 + 2)) * 3", "(1 + 2) * 3", ast.IgnorePositions, true},
+		{"f(a, b)", "f(a, b)", ast.IgnorePositions, true},
+		{"f(a, b)", "f(a)", ast.IgnorePositions, false},
+		{"f(a, b)", "f(b, a)", ast.IgnorePositions, false},
+		{"if (x) { 1 }", "if (x) { 1 } else { 2 }", ast.IgnorePositions, false},
+		{`"a"`, `"b"`, ast.IgnorePositions, false},
+		{"true", "false", ast.IgnorePositions, false},
+		{"x; // comment", "x;", ast.IgnorePositions, false},
+		{"x; // comment", "x;", ast.IgnorePositions | ast.IgnoreComments, true},
+		{"// doc\nvar x = 1;", "var x = 1;", ast.IgnorePositions | ast.IgnoreComments, true},
+		{"// doc\nvar x = 1;", "// other\nvar x = 1;", ast.IgnorePositions, false},
+	}
+
+	for _, tt := range tests {
+		a, b := parseProgram(t, tt.a), parseProgram(t, tt.b)
+		if got := ast.Equal(a, b, tt.opts); got != tt.expected {
+			t.Errorf("Equal(%q, %q, %b) wrong. expected=%t, got=%t", tt.a, tt.b, tt.opts, tt.expected, got)
+		}
+		if got := ast.Equal(b, a, tt.opts); got != tt.expected {
+			t.Errorf("Equal(%q, %q, %b) wrong. expected=%t, got=%t", tt.b, tt.a, tt.opts, tt.expected, got)
+		}
+		if tt.expected && ast.Hash(a, tt.opts) != ast.Hash(b, tt.opts) {
+			t.Errorf("Hash of %q and %q with %b differ, but the trees are equal", tt.a, tt.b, tt.opts)
+		}
+		if !tt.expected && ast.Hash(a, tt.opts) == ast.Hash(b, tt.opts) {
+			t.Errorf("Hash of %q and %q with %b are the same, but the trees differ", tt.a, tt.b, tt.opts)
+		}
+	}
+}
+
+func TestEqualExpectedTree(t *testing.T) {
+	ident := func(name string) *ast.Identifier {
+		return &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+	}
+	expected := &ast.Program{Statements: []ast.Statement{
+		&ast.VarStatement{
+			Token: token.Token{Type: token.VAR, Literal: "var"},
+			Name:  ident("x"),
+			Value: &ast.InfixExpression{
+				Operator: token.Token{Type: token.PLUS, Literal: "+"},
+				Left: &ast.PrefixExpression{
+					Token: token.Token{Type: token.MINUS, Literal: "-"},
+					Right: ident("a"),
+				},
+				Right: &ast.CallExpression{
+					Token:     token.Token{Type: token.LPAREN, Literal: "("},
+					Function:  ident("f"),
+					Arguments: []ast.Expression{integer(1)},
+					Rparen:    token.Token{Type: token.RPAREN, Literal: ")"},
+				},
+			},
+		},
+	}}
+
+	program := parseProgram(t, "var x = -a + f(1);")
+	if !ast.Equal(program, expected, ast.IgnorePositions) {
+		t.Errorf("program wrong. expected=%s, got=%s", ast.Sexp(expected), ast.Sexp(program))
+	}
+	if ast.Equal(program, expected, 0) {
+		t.Errorf("program equal to the expected tree, which has no positions")
+	}
+}
+
+func TestEqualNil(t *testing.T) {
+	var ident *ast.Identifier
+	if !ast.Equal(nil, nil, 0) || !ast.Equal(nil, ident, 0) || !ast.Equal(ident, nil, 0) {
+		t.Errorf("nil nodes not equal")
+	}
+	if ast.Equal(nil, integer(1), 0) || ast.Equal(integer(1), ident, 0) {
+		t.Errorf("nil node equal to a literal")
+	}
+	if ast.Hash(nil, 0) != ast.Hash(ident, 0) {
+		t.Errorf("Hash of nil nodes differ")
+	}
+
+	// an if without else and one whose alternative is a nil block are the same
+	a := parseProgram(t, "if (x) { 1 }")
+	b := parseProgram(t, "if (x) { 1 }")
+	b.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression).Alternative = nil
+	if !ast.Equal(a, b, 0) {
+		t.Errorf("if expressions without alternative not equal")
+	}
+}
+
+func TestEqualResolution(t *testing.T) {
+	a := parseProgram(t, "x")
+	b := parseProgram(t, "x")
+	b.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.Identifier).Resolution = &ast.Resolution{Depth: 1}
+
+	if ast.Equal(a, b, 0) {
+		t.Errorf("identifiers with different resolutions equal")
+	}
+	if !ast.Equal(a, b, ast.IgnoreResolution) {
+		t.Errorf("identifiers not equal when ignoring resolutions")
+	}
+	if ast.Hash(a, ast.IgnoreResolution) != ast.Hash(b, ast.IgnoreResolution) {
+		t.Errorf("Hash of identifiers differ when ignoring resolutions")
+	}
+}
+
+func TestHashStable(t *testing.T) {
+	// the hash must not change between runs or processes, as it may be stored
+	program := parseProgram(t, "var x = f(1, \"a\");")
+	first := ast.Hash(program, 0)
+	for i := 0; i < 10; i++ {
+		if got := ast.Hash(parseProgram(t, "var x = f(1, \"a\");"), 0); got != first {
+			t.Fatalf("Hash changed. expected=%d, got=%d", first, got)
+		}
+	}
+	if ast.Hash(program, 0) == ast.Hash(program, ast.IgnorePositions) {
+		t.Errorf("Hash with and without positions are the same")
+	}
+}
@@ -0,0 +1,594 @@
+package ast
+
+import (
+	"blank/token"
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Nodes marshal to JSON objects with this schema:
+//
+//	node     = { "kind": string, "pos": position, "end": position, fields... }
+//	position = { "filename": string (omitted when empty), "line": int, "column": int, "offset": int }
+//	token    = { "type": string, "literal": string, "pos": position, "end": position }
+//
+// "kind" is the name of the node type, e.g. "InfixExpression", "pos" and "end" are
+// those of Pos() and End(). They are followed by the fields of the kind, where the
+// child nodes are nodes, or null when missing:
+//
+//	Program             "statements": [node], "comments": [CommentGroup]
+//	VarStatement        "token": token, "name": Identifier, "value": node, "constant": bool, "doc": CommentGroup (omitted when nil)
+//	ReturnStatement     "token": token, "returnValue": node
+//	ExpressionStatement "token": token, "expression": node
+//	BlockStatement      "token": token, "statements": [node], "rbrace": token
+//	Identifier          "token": token, "value": string, "resolution": { "depth": int, "slot": int } (omitted when nil)
+//	IntegerLiteral      "token": token, "value": int
+//	StringLiteral       "token": token, "value": string
+//	BooleanExpression   "token": token, "value": bool
+//	PrefixExpression    "token": token, "right": node
+//	InfixExpression     "operator": token, "left": node, "right": node
+//	IfExpression        "token": token, "condition": node, "consequence": BlockStatement, "alternative": BlockStatement
+//	CallExpression      "token": token, "function": node, "arguments": [node], "rparen": token
+//	CommentGroup        "list": [Comment]
+//	Comment             "token": token
+//
+// Unmarshal reads them back. "pos" and "end" are ignored there, as the tokens carry the positions.
+// It only reads the trees of valid sources: the alternative of an IfExpression is the only
+// child node that may be null, the trees with missing children the parser returns on
+// syntax errors are rejected.
+
+// kinds creates an empty node of each kind Unmarshal knows
+var kinds = map[string]func() Node{
+	"Program":             func() Node { return &Program{} },
+	"VarStatement":        func() Node { return &VarStatement{} },
+	"ReturnStatement":     func() Node { return &ReturnStatement{} },
+	"ExpressionStatement": func() Node { return &ExpressionStatement{} },
+	"BlockStatement":      func() Node { return &BlockStatement{} },
+	"Identifier":          func() Node { return &Identifier{} },
+	"IntegerLiteral":      func() Node { return &IntegerLiteral{} },
+	"StringLiteral":       func() Node { return &StringLiteral{} },
+	"BooleanExpression":   func() Node { return &BooleanExpression{} },
+	"PrefixExpression":    func() Node { return &PrefixExpression{} },
+	"InfixExpression":     func() Node { return &InfixExpression{} },
+	"IfExpression":        func() Node { return &IfExpression{} },
+	"CallExpression":      func() Node { return &CallExpression{} },
+}
+
+// Unmarshal decodes a node of any kind from its JSON. null decodes to a nil node
+func Unmarshal(data []byte) (Node, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	var header struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	newNode, ok := kinds[header.Kind]
+	if !ok {
+		return nil, fmt.Errorf("ast: unknown node kind %q", header.Kind)
+	}
+	node := newNode()
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// header holds the fields every node starts with
+type header struct {
+	Kind string    `json:"kind"`
+	Pos  token.Pos `json:"pos"`
+	End  token.Pos `json:"end"`
+}
+
+func newHeader(kind string, n interface {
+	Pos() token.Pos
+	End() token.Pos
+}) header {
+	return header{Kind: kind, Pos: n.Pos(), End: n.End()}
+}
+
+// check returns an error when the JSON of a node of kind had another kind
+func (h header) check(kind string) error {
+	if h.Kind != kind {
+		return fmt.Errorf("ast: cannot unmarshal %q node into %s", h.Kind, kind)
+	}
+	return nil
+}
+
+func isNull(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) == 0 || string(data) == "null"
+}
+
+func unmarshalExpression(data json.RawMessage) (Expression, error) {
+	node, err := Unmarshal(data)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	exp, ok := node.(Expression)
+	if !ok {
+		return nil, fmt.Errorf("ast: %T is not an expression", node)
+	}
+	return exp, nil
+}
+
+// requireExpression decodes the field of a node of kind, an expression that cannot be missing
+func requireExpression(data json.RawMessage, kind, field string) (Expression, error) {
+	if isNull(data) {
+		return nil, missing(kind, field)
+	}
+	return unmarshalExpression(data)
+}
+
+func missing(kind, field string) error {
+	return fmt.Errorf("ast: %s without %s", kind, field)
+}
+
+func unmarshalStatements(data []json.RawMessage) ([]Statement, error) {
+	var statements []Statement
+	for _, d := range data {
+		node, err := Unmarshal(d)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := node.(Statement)
+		if !ok {
+			return nil, fmt.Errorf("ast: %T is not a statement", node)
+		}
+		statements = append(statements, s)
+	}
+	return statements, nil
+}
+
+func (p *Program) MarshalJSON() ([]byte, error) {
+	comments := p.Comments
+	if comments == nil {
+		comments = []*CommentGroup{}
+	}
+	return json.Marshal(struct {
+		header
+		Statements []Statement     `json:"statements"`
+		Comments   []*CommentGroup `json:"comments"`
+	}{newHeader("Program", p), nonNil(p.Statements), comments})
+}
+
+func (p *Program) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Statements []json.RawMessage `json:"statements"`
+		Comments   []*CommentGroup   `json:"comments"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("Program"); err != nil {
+		return err
+	}
+	statements, err := unmarshalStatements(v.Statements)
+	if err != nil {
+		return err
+	}
+	*p = Program{Statements: statements, Comments: v.Comments}
+	return nil
+}
+
+// nonNil returns statements, or an empty slice instead of nil so that it marshals to []
+func nonNil(statements []Statement) []Statement {
+	if statements == nil {
+		return []Statement{}
+	}
+	return statements
+}
+
+func (vs *VarStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token    token.Token   `json:"token"`
+		Name     *Identifier   `json:"name"`
+		Value    Expression    `json:"value"`
+		Constant bool          `json:"constant"`
+		Doc      *CommentGroup `json:"doc,omitempty"`
+	}{newHeader("VarStatement", vs), vs.Token, vs.Name, vs.Value, vs.Constant, vs.Doc})
+}
+
+func (vs *VarStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token    token.Token     `json:"token"`
+		Name     *Identifier     `json:"name"`
+		Value    json.RawMessage `json:"value"`
+		Constant bool            `json:"constant"`
+		Doc      *CommentGroup   `json:"doc"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("VarStatement"); err != nil {
+		return err
+	}
+	if v.Name == nil {
+		return missing("VarStatement", "name")
+	}
+	value, err := requireExpression(v.Value, "VarStatement", "value")
+	if err != nil {
+		return err
+	}
+	*vs = VarStatement{Token: v.Token, Name: v.Name, Value: value, Constant: v.Constant, Doc: v.Doc}
+	return nil
+}
+
+func (rs *ReturnStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token       token.Token `json:"token"`
+		ReturnValue Expression  `json:"returnValue"`
+	}{newHeader("ReturnStatement", rs), rs.Token, rs.ReturnValue})
+}
+
+func (rs *ReturnStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token       token.Token     `json:"token"`
+		ReturnValue json.RawMessage `json:"returnValue"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("ReturnStatement"); err != nil {
+		return err
+	}
+	value, err := requireExpression(v.ReturnValue, "ReturnStatement", "returnValue")
+	if err != nil {
+		return err
+	}
+	*rs = ReturnStatement{Token: v.Token, ReturnValue: value}
+	return nil
+}
+
+func (es *ExpressionStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token      token.Token `json:"token"`
+		Expression Expression  `json:"expression"`
+	}{newHeader("ExpressionStatement", es), es.Token, es.Expression})
+}
+
+func (es *ExpressionStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token      token.Token     `json:"token"`
+		Expression json.RawMessage `json:"expression"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("ExpressionStatement"); err != nil {
+		return err
+	}
+	exp, err := requireExpression(v.Expression, "ExpressionStatement", "expression")
+	if err != nil {
+		return err
+	}
+	*es = ExpressionStatement{Token: v.Token, Expression: exp}
+	return nil
+}
+
+func (bs *BlockStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token      token.Token `json:"token"`
+		Statements []Statement `json:"statements"`
+		Rbrace     token.Token `json:"rbrace"`
+	}{newHeader("BlockStatement", bs), bs.Token, nonNil(bs.Statements), bs.Rbrace})
+}
+
+func (bs *BlockStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token      token.Token       `json:"token"`
+		Statements []json.RawMessage `json:"statements"`
+		Rbrace     token.Token       `json:"rbrace"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("BlockStatement"); err != nil {
+		return err
+	}
+	statements, err := unmarshalStatements(v.Statements)
+	if err != nil {
+		return err
+	}
+	*bs = BlockStatement{Token: v.Token, Statements: statements, Rbrace: v.Rbrace}
+	return nil
+}
+
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token      token.Token `json:"token"`
+		Value      string      `json:"value"`
+		Resolution *Resolution `json:"resolution,omitempty"`
+	}{newHeader("Identifier", i), i.Token, i.Value, i.Resolution})
+}
+
+func (i *Identifier) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token      token.Token `json:"token"`
+		Value      string      `json:"value"`
+		Resolution *Resolution `json:"resolution"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("Identifier"); err != nil {
+		return err
+	}
+	*i = Identifier{Token: v.Token, Value: v.Value, Resolution: v.Resolution}
+	return nil
+}
+
+func (il *IntegerLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token token.Token `json:"token"`
+		Value int64       `json:"value"`
+	}{newHeader("IntegerLiteral", il), il.Token, il.Value})
+}
+
+func (il *IntegerLiteral) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token token.Token `json:"token"`
+		Value int64       `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("IntegerLiteral"); err != nil {
+		return err
+	}
+	*il = IntegerLiteral{Token: v.Token, Value: v.Value}
+	return nil
+}
+
+func (sl *StringLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token token.Token `json:"token"`
+		Value string      `json:"value"`
+	}{newHeader("StringLiteral", sl), sl.Token, sl.Value})
+}
+
+func (sl *StringLiteral) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token token.Token `json:"token"`
+		Value string      `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("StringLiteral"); err != nil {
+		return err
+	}
+	*sl = StringLiteral{Token: v.Token, Value: v.Value}
+	return nil
+}
+
+func (b *BooleanExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token token.Token `json:"token"`
+		Value bool        `json:"value"`
+	}{newHeader("BooleanExpression", b), b.Token, b.Value})
+}
+
+func (b *BooleanExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token token.Token `json:"token"`
+		Value bool        `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("BooleanExpression"); err != nil {
+		return err
+	}
+	*b = BooleanExpression{Token: v.Token, Value: v.Value}
+	return nil
+}
+
+func (pe *PrefixExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token token.Token `json:"token"`
+		Right Expression  `json:"right"`
+	}{newHeader("PrefixExpression", pe), pe.Token, pe.Right})
+}
+
+func (pe *PrefixExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token token.Token     `json:"token"`
+		Right json.RawMessage `json:"right"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("PrefixExpression"); err != nil {
+		return err
+	}
+	right, err := requireExpression(v.Right, "PrefixExpression", "right")
+	if err != nil {
+		return err
+	}
+	*pe = PrefixExpression{Token: v.Token, Right: right}
+	return nil
+}
+
+func (ip *InfixExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Operator token.Token `json:"operator"`
+		Left     Expression  `json:"left"`
+		Right    Expression  `json:"right"`
+	}{newHeader("InfixExpression", ip), ip.Operator, ip.Left, ip.Right})
+}
+
+func (ip *InfixExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Operator token.Token     `json:"operator"`
+		Left     json.RawMessage `json:"left"`
+		Right    json.RawMessage `json:"right"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("InfixExpression"); err != nil {
+		return err
+	}
+	left, err := requireExpression(v.Left, "InfixExpression", "left")
+	if err != nil {
+		return err
+	}
+	right, err := requireExpression(v.Right, "InfixExpression", "right")
+	if err != nil {
+		return err
+	}
+	*ip = InfixExpression{Operator: v.Operator, Left: left, Right: right}
+	return nil
+}
+
+func (ie *IfExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token       token.Token     `json:"token"`
+		Condition   Expression      `json:"condition"`
+		Consequence *BlockStatement `json:"consequence"`
+		Alternative *BlockStatement `json:"alternative"`
+	}{newHeader("IfExpression", ie), ie.Token, ie.Condition, ie.Consequence, ie.Alternative})
+}
+
+func (ie *IfExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token       token.Token     `json:"token"`
+		Condition   json.RawMessage `json:"condition"`
+		Consequence *BlockStatement `json:"consequence"`
+		Alternative *BlockStatement `json:"alternative"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("IfExpression"); err != nil {
+		return err
+	}
+	condition, err := requireExpression(v.Condition, "IfExpression", "condition")
+	if err != nil {
+		return err
+	}
+	if v.Consequence == nil {
+		return missing("IfExpression", "consequence")
+	}
+	*ie = IfExpression{Token: v.Token, Condition: condition, Consequence: v.Consequence, Alternative: v.Alternative}
+	return nil
+}
+
+func (ce *CallExpression) MarshalJSON() ([]byte, error) {
+	arguments := ce.Arguments
+	if arguments == nil {
+		arguments = []Expression{}
+	}
+	return json.Marshal(struct {
+		header
+		Token     token.Token  `json:"token"`
+		Function  Expression   `json:"function"`
+		Arguments []Expression `json:"arguments"`
+		Rparen    token.Token  `json:"rparen"`
+	}{newHeader("CallExpression", ce), ce.Token, ce.Function, arguments, ce.Rparen})
+}
+
+func (ce *CallExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token     token.Token       `json:"token"`
+		Function  json.RawMessage   `json:"function"`
+		Arguments []json.RawMessage `json:"arguments"`
+		Rparen    token.Token       `json:"rparen"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("CallExpression"); err != nil {
+		return err
+	}
+	function, err := requireExpression(v.Function, "CallExpression", "function")
+	if err != nil {
+		return err
+	}
+	arguments := []Expression{}
+	for _, a := range v.Arguments {
+		arg, err := requireExpression(a, "CallExpression", "argument")
+		if err != nil {
+			return err
+		}
+		arguments = append(arguments, arg)
+	}
+	*ce = CallExpression{Token: v.Token, Function: function, Arguments: arguments, Rparen: v.Rparen}
+	return nil
+}
+
+func (g *CommentGroup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		List []*Comment `json:"list"`
+	}{newHeader("CommentGroup", g), g.List})
+}
+
+func (g *CommentGroup) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		List []*Comment `json:"list"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("CommentGroup"); err != nil {
+		return err
+	}
+	if len(v.List) == 0 {
+		return fmt.Errorf("ast: empty CommentGroup")
+	}
+	*g = CommentGroup{List: v.List}
+	return nil
+}
+
+func (c *Comment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		header
+		Token token.Token `json:"token"`
+	}{newHeader("Comment", c), c.Token})
+}
+
+func (c *Comment) UnmarshalJSON(data []byte) error {
+	var v struct {
+		header
+		Token token.Token `json:"token"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := v.check("Comment"); err != nil {
+		return err
+	}
+	*c = Comment{Token: v.Token}
+	return nil
+}
@@ -0,0 +1,132 @@
+package ast_test
+
+import (
+	"blank/ast"
+	"blank/lexer"
+	"blank/parser"
+	"blank/resolver"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const jsonSource = `// every kind of node
+/// the limit
+const limit = 10;
+var name = "café";
+if (!(limit > 5) == false) {
+	var twice = limit * 2;
+	return twice;
+} else {
+	puts(name, -limit);
+}
+`
+
+func TestJSONRoundTrip(t *testing.T) {
+	p := parser.New(lexer.NewFile("main.blank", jsonSource))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	r := resolver.New()
+	r.Declare(true, "puts")
+	r.Resolve(program)
+
+	data, err := json.Marshal(program)
+	if err != nil {
+		t.Fatalf("marshal failed: %s", err)
+	}
+	for _, kind := range []string{"Program", "VarStatement", "ReturnStatement", "ExpressionStatement",
+		"BlockStatement", "Identifier", "IntegerLiteral", "StringLiteral", "BooleanExpression",
+		"PrefixExpression", "InfixExpression", "IfExpression", "CallExpression", "CommentGroup", "Comment"} {
+		if !bytes.Contains(data, []byte(`"kind":"`+kind+`"`)) {
+			t.Errorf("JSON has no %s node", kind)
+		}
+	}
+
+	node, err := ast.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unmarshal failed: %s", err)
+	}
+	decoded, ok := node.(*ast.Program)
+	if !ok {
+		t.Fatalf("node is not *ast.Program. got=%T", node)
+	}
+	if decoded.String() != program.String() {
+		t.Errorf("decoded program wrong.\nexpected=%q\ngot=     %q", program.String(), decoded.String())
+	}
+	if decoded.End() != program.End() {
+		t.Errorf("decoded program end wrong. expected=%s, got=%s", program.End(), decoded.End())
+	}
+	if doc := decoded.Statements[0].(*ast.VarStatement).Doc.Text(); doc != "the limit" {
+		t.Errorf("decoded doc comment wrong. got=%q", doc)
+	}
+
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("marshal of decoded program failed: %s", err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Errorf("JSON changed by a round trip.\nexpected=%s\ngot=     %s", data, again)
+	}
+
+	var into ast.Program
+	if err := json.Unmarshal(data, &into); err != nil || into.String() != program.String() {
+		t.Errorf("json.Unmarshal into ast.Program wrong. got=%q (%v)", into.String(), err)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	p := parser.New(lexer.New("-x"))
+	program := p.ParseProgram()
+
+	data, err := json.Marshal(program.Statements[0].(*ast.ExpressionStatement).Expression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"kind":"PrefixExpression","pos":{"line":1,"column":1,"offset":0},"end":{"line":1,"column":3,"offset":2},` +
+		`"token":{"type":"-","literal":"-","pos":{"line":1,"column":1,"offset":0},"end":{"line":1,"column":2,"offset":1}},` +
+		`"right":{"kind":"Identifier","pos":{"line":1,"column":2,"offset":1},"end":{"line":1,"column":3,"offset":2},` +
+		`"token":{"type":"IDENT","literal":"x","pos":{"line":1,"column":2,"offset":1},"end":{"line":1,"column":3,"offset":2}},"value":"x"}}`
+	if string(data) != expected {
+		t.Errorf("wrong JSON.\nexpected=%s\ngot=     %s", expected, data)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"kind":"Loop"}`, `ast: unknown node kind "Loop"`},
+		{`{"kind":"Program","statements":[{"kind":"Identifier"}]}`, "ast: *ast.Identifier is not a statement"},
+		{`{"kind":"PrefixExpression","right":{"kind":"BlockStatement"}}`, "ast: *ast.BlockStatement is not an expression"},
+		{`{"kind":"IfExpression","consequence":{"kind":"Identifier"}}`, `ast: cannot unmarshal "Identifier" node into BlockStatement`},
+		{`{"kind":"Program","comments":[{"kind":"CommentGroup","list":[]}]}`, "ast: empty CommentGroup"},
+		{`{"kind":"IfExpression"}`, "ast: IfExpression without condition"},
+		{`{"kind":"IfExpression","condition":{"kind":"Identifier"}}`, "ast: IfExpression without consequence"},
+		{`{"kind":"VarStatement"}`, "ast: VarStatement without name"},
+		{`{"kind":"VarStatement","name":{"kind":"Identifier"},"value":null}`, "ast: VarStatement without value"},
+		{`{"kind":"ReturnStatement"}`, "ast: ReturnStatement without returnValue"},
+		{`{"kind":"ExpressionStatement"}`, "ast: ExpressionStatement without expression"},
+		{`{"kind":"PrefixExpression"}`, "ast: PrefixExpression without right"},
+		{`{"kind":"InfixExpression","right":{"kind":"Identifier"}}`, "ast: InfixExpression without left"},
+		{`{"kind":"InfixExpression","left":{"kind":"Identifier"}}`, "ast: InfixExpression without right"},
+		{`{"kind":"CallExpression"}`, "ast: CallExpression without function"},
+		{`{"kind":"CallExpression","function":{"kind":"Identifier"},"arguments":[null]}`, "ast: CallExpression without argument"},
+		{`{"kind":"Program","statements":[null]}`, "ast: <nil> is not a statement"},
+		{`[]`, "json: cannot unmarshal array"},
+	}
+
+	for _, tt := range tests {
+		_, err := ast.Unmarshal([]byte(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("input %s: wrong error. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+
+	if node, err := ast.Unmarshal([]byte("null")); node != nil || err != nil {
+		t.Errorf("null wrong. got=%v (%v)", node, err)
+	}
+}
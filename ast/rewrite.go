@@ -0,0 +1,237 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyFunc is called by Apply for each node, with a cursor positioned at it
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses the tree of root like Walk, calling pre before and post after
+// the children of each non-nil node, in source order. Either function may be nil.
+//
+// If pre returns false, the children of the node are not traversed and post is
+// not called for it. If post returns false, the traversal stops and Apply returns.
+//
+// The functions may change the tree through the Cursor: replace the current
+// node, and delete it or insert nodes around it when it is in a list. The
+// children of a node are traversed after pre ran, so when pre replaced the node
+// those of the replacement are traversed. Inserted nodes are not traversed.
+// Apply returns the root, which differs from root if it was replaced
+func Apply(root Node, pre, post ApplyFunc) (result Node) {
+	parent := &struct{ Node }{root}
+	defer func() {
+		if r := recover(); r != nil && r != abort {
+			panic(r)
+		}
+		result = parent.Node
+	}()
+	a := &application{pre: pre, post: post}
+	a.apply(parent, "Node", nil, root)
+	return
+}
+
+// Modify replaces each node of the tree of node by the node modifier returns for it,
+// the children first. It returns the modified tree, which is the node modifier
+// returned for node itself. modifier may return its argument to keep it. Modify
+// panics if modifier returns a node that does not fit where the original was,
+// e.g. a statement in place of an expression
+func Modify(node Node, modifier func(Node) Node) Node {
+	return Apply(node, nil, func(c *Cursor) bool {
+		if replacement := modifier(c.Node()); replacement != c.Node() {
+			c.Replace(replacement)
+		}
+		return true
+	})
+}
+
+// Cursor describes a node met during Apply, and where it is in its parent
+type Cursor struct {
+	parent Node
+	name   string
+	iter   *iterator // nil unless the node is in a list
+	node   Node
+}
+
+type iterator struct {
+	index, step int
+}
+
+var abort = new(int) // panicked with to stop Apply
+
+// Node returns the current node
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the node that has the current node as a child.
+// For the root of Apply it is a placeholder node
+func (c *Cursor) Parent() Node { return c.parent }
+
+// Name returns the name of the field of the parent holding the current node,
+// e.g. "Left" or "Statements"
+func (c *Cursor) Name() string { return c.name }
+
+// Index returns the index of the current node in the list field Name of its parent,
+// or a negative value when it is not in a list
+func (c *Cursor) Index() int {
+	if c.iter != nil {
+		return c.iter.index
+	}
+	return -1
+}
+
+// field returns the field of the parent holding the current node
+func (c *Cursor) field() reflect.Value {
+	return reflect.Indirect(reflect.ValueOf(c.parent)).FieldByName(c.name)
+}
+
+// Replace puts n in place of the current node. It panics if n does not fit the field holding it
+func (c *Cursor) Replace(n Node) {
+	v := c.field()
+	if c.iter != nil {
+		v = v.Index(c.iter.index)
+	}
+	v.Set(nodeValue(n, v.Type()))
+	c.node = n
+}
+
+// Delete removes the current node from its list. It panics if the node is not in a list
+func (c *Cursor) Delete() {
+	i := c.listIndex("Delete")
+	v := c.field()
+	l := v.Len()
+	reflect.Copy(v.Slice(i, l), v.Slice(i+1, l))
+	v.Index(l - 1).Set(reflect.Zero(v.Type().Elem()))
+	v.SetLen(l - 1)
+	c.iter.step--
+}
+
+// InsertAfter inserts n after the current node in its list, where it is not traversed.
+// It panics if the current node is not in a list or n does not fit in it
+func (c *Cursor) InsertAfter(n Node) {
+	i := c.listIndex("InsertAfter")
+	c.insert(i+1, n)
+	c.iter.step++
+}
+
+// InsertBefore inserts n before the current node in its list, where it is not traversed.
+// It panics if the current node is not in a list or n does not fit in it
+func (c *Cursor) InsertBefore(n Node) {
+	i := c.listIndex("InsertBefore")
+	c.insert(i, n)
+	c.iter.index++
+}
+
+func (c *Cursor) listIndex(method string) int {
+	if c.iter == nil {
+		panic(fmt.Sprintf("ast: Cursor.%s of a node not in a list, but in field %s of %T", method, c.name, c.parent))
+	}
+	return c.iter.index
+}
+
+// insert puts n at index i of the list holding the current node
+func (c *Cursor) insert(i int, n Node) {
+	v := c.field()
+	x := nodeValue(n, v.Type().Elem())
+	l := v.Len()
+	v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	reflect.Copy(v.Slice(i+1, l+1), v.Slice(i, l))
+	v.Index(i).Set(x)
+}
+
+// nodeValue returns n as a value to store in a field of type t, panicking if it does not fit
+func nodeValue(n Node, t reflect.Type) reflect.Value {
+	if n == nil {
+		return reflect.Zero(t)
+	}
+	v := reflect.ValueOf(n)
+	if !v.Type().AssignableTo(t) {
+		panic(fmt.Sprintf("ast: cannot put a %T where a %s is expected", n, t))
+	}
+	return v
+}
+
+type application struct {
+	pre, post ApplyFunc
+	cursor    Cursor
+	iter      iterator
+}
+
+func (a *application) apply(parent Node, name string, iter *iterator, n Node) {
+	saved := a.cursor
+	a.cursor = Cursor{parent: parent, name: name, iter: iter, node: n}
+	if a.pre != nil && !a.pre(&a.cursor) {
+		a.cursor = saved
+		return
+	}
+
+	switch n := a.cursor.node.(type) {
+	// Statements
+	case *Program:
+		a.applyList(n, "Statements")
+	case *VarStatement:
+		if n.Name != nil {
+			a.apply(n, "Name", nil, n.Name)
+		}
+		a.applyExpression(n, "Value", n.Value)
+	case *ReturnStatement:
+		a.applyExpression(n, "ReturnValue", n.ReturnValue)
+	case *ExpressionStatement:
+		a.applyExpression(n, "Expression", n.Expression)
+	case *BlockStatement:
+		a.applyList(n, "Statements")
+
+	// Expressions
+	case *Identifier, *IntegerLiteral, *StringLiteral, *BooleanExpression:
+		// no children
+	case *PrefixExpression:
+		a.applyExpression(n, "Right", n.Right)
+	case *InfixExpression:
+		a.applyExpression(n, "Left", n.Left)
+		a.applyExpression(n, "Right", n.Right)
+	case *IfExpression:
+		a.applyExpression(n, "Condition", n.Condition)
+		if n.Consequence != nil {
+			a.apply(n, "Consequence", nil, n.Consequence)
+		}
+		if n.Alternative != nil {
+			a.apply(n, "Alternative", nil, n.Alternative)
+		}
+	case *CallExpression:
+		a.applyExpression(n, "Function", n.Function)
+		a.applyList(n, "Arguments")
+	case nil:
+		// the node was replaced by nil
+	default:
+		panic(fmt.Sprintf("ast.Apply: unexpected node type %T", n))
+	}
+
+	if a.post != nil && a.cursor.node != nil && !a.post(&a.cursor) {
+		panic(abort)
+	}
+	a.cursor = saved
+}
+
+func (a *application) applyExpression(parent Node, name string, exp Expression) {
+	if exp != nil {
+		a.apply(parent, name, nil, exp)
+	}
+}
+
+// applyList applies to each non-nil node of the list field name of parent, which may change as it goes
+func (a *application) applyList(parent Node, name string) {
+	saved := a.iter
+	a.iter.index = 0
+	for {
+		v := reflect.Indirect(reflect.ValueOf(parent)).FieldByName(name)
+		if a.iter.index >= v.Len() {
+			break
+		}
+		a.iter.step = 1
+		if e := v.Index(a.iter.index); !e.IsNil() {
+			a.apply(parent, name, &a.iter, e.Interface().(Node))
+		}
+		a.iter.index += a.iter.step
+	}
+	a.iter = saved
+}
@@ -0,0 +1,181 @@
+package ast_test
+
+import (
+	"blank/ast"
+	"blank/lexer"
+	"blank/parser"
+	"blank/token"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
+
+func integer(value int64) *ast.IntegerLiteral {
+	literal := fmt.Sprint(value)
+	return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: literal}, Value: value}
+}
+
+// fold replaces the infix expressions of two integer literals by their value
+func fold(node ast.Node) ast.Node {
+	infix, ok := node.(*ast.InfixExpression)
+	if !ok {
+		return node
+	}
+	left, ok := infix.Left.(*ast.IntegerLiteral)
+	if !ok {
+		return node
+	}
+	right, ok := infix.Right.(*ast.IntegerLiteral)
+	if !ok {
+		return node
+	}
+	switch infix.Operator.Literal {
+	case "+":
+		return integer(left.Value + right.Value)
+	case "-":
+		return integer(left.Value - right.Value)
+	case "*":
+		return integer(left.Value * right.Value)
+	}
+	return node
+}
+
+func TestModify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "(program 7)"},
+		{"var x = (1 + 2) * (10 - 4); x * (2 - 1)", "(program (var x 18) (* x 1))"},
+		{"if (1 + 1 == 2) { return 2 * 2; } else { f(3 - 1, 1 + x) }",
+			"(program (if (== 2 2) (block (return 4)) (block (call f 2 (+ 1 x)))))"},
+		{"1 / 2", "(program (/ 1 2))"},
+	}
+
+	for _, tt := range tests {
+		program := ast.Modify(parseProgram(t, tt.input), fold)
+		if got := ast.Sexp(program); got != tt.expected {
+			t.Errorf("input %q: wrong tree. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestModifyRoot(t *testing.T) {
+	exp := parseProgram(t, "2 * 21").Statements[0].(*ast.ExpressionStatement).Expression
+	if got := ast.Sexp(ast.Modify(exp, fold)); got != "42" {
+		t.Errorf("wrong root. got=%q", got)
+	}
+}
+
+func TestApplyCursor(t *testing.T) {
+	program := parseProgram(t, "var x = a; f(b, c);")
+
+	var visits []string
+	ast.Apply(program, func(c *ast.Cursor) bool {
+		if ident, ok := c.Node().(*ast.Identifier); ok {
+			visits = append(visits, fmt.Sprintf("%s:%T.%s[%d]", ident.Value, c.Parent(), c.Name(), c.Index()))
+		}
+		return true
+	}, nil)
+
+	expected := "x:*ast.VarStatement.Name[-1] a:*ast.VarStatement.Value[-1] " +
+		"f:*ast.CallExpression.Function[-1] b:*ast.CallExpression.Arguments[0] c:*ast.CallExpression.Arguments[1]"
+	if got := strings.Join(visits, " "); got != expected {
+		t.Errorf("wrong cursors.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
+
+func TestApplyDeleteAndInsert(t *testing.T) {
+	program := parseProgram(t, `var x = 1; puts(x); if (x) { puts(1); var y = 2; puts(y) } f(puts, 2, 3);`)
+
+	var seen []string
+	ast.Apply(program, func(c *ast.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.ExpressionStatement:
+			// drop the puts calls
+			if call, ok := n.Expression.(*ast.CallExpression); ok && call.Function.String() == "puts" {
+				c.Delete()
+				return false
+			}
+		case *ast.VarStatement:
+			// follow each declaration with a use, and precede it with a marker
+			c.InsertAfter(&ast.ExpressionStatement{Expression: n.Name})
+			c.InsertBefore(&ast.ExpressionStatement{Expression: integer(0)})
+		case *ast.IntegerLiteral:
+			if c.Name() == "Arguments" && n.Value == 2 {
+				c.Delete()
+			}
+		}
+		seen = append(seen, ast.Sexp(c.Node()))
+		return true
+	}, nil)
+
+	expected := "(program 0 (var x 1) x (if x (block 0 (var y 2) y)) (call f puts 3))"
+	if got := ast.Sexp(program); got != expected {
+		t.Errorf("wrong tree.\nexpected=%q\ngot=     %q", expected, got)
+	}
+	// inserted nodes are not traversed: y is only seen as the name of its declaration
+	count := map[string]int{}
+	for _, s := range seen {
+		count[s]++
+	}
+	if count["0"] != 0 || count["y"] != 1 {
+		t.Errorf("wrong traversal of inserted nodes. got=%v", seen)
+	}
+}
+
+func TestApplyAbort(t *testing.T) {
+	program := parseProgram(t, "1; 2; 3;")
+	var visited []string
+	ast.Apply(program, nil, func(c *ast.Cursor) bool {
+		visited = append(visited, ast.Sexp(c.Node()))
+		return c.Node().String() != "2"
+	})
+	if got := strings.Join(visited, " "); got != "1 1 2" {
+		t.Errorf("wrong visits before abort. got=%q", got)
+	}
+}
+
+func TestApplyPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		apply    func(c *ast.Cursor)
+		expected string
+	}{
+		{"statement for expression", "-x", func(c *ast.Cursor) {
+			if c.Name() == "Right" {
+				c.Replace(&ast.ReturnStatement{})
+			}
+		}, "ast: cannot put a *ast.ReturnStatement where a ast.Expression is expected"},
+		{"delete outside a list", "var x = 1;", func(c *ast.Cursor) {
+			if c.Name() == "Value" {
+				c.Delete()
+			}
+		}, "ast: Cursor.Delete of a node not in a list, but in field Value of *ast.VarStatement"},
+	}
+
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		func() {
+			defer func() {
+				if r := recover(); fmt.Sprint(r) != tt.expected {
+					t.Errorf("%s: wrong panic. expected=%q, got=%v", tt.name, tt.expected, r)
+				}
+			}()
+			ast.Apply(program, func(c *ast.Cursor) bool {
+				tt.apply(c)
+				return true
+			}, nil)
+		}()
+	}
+}
@@ -0,0 +1,85 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Sexp renders node as an S-expression that shows the shape of the tree, e.g.
+// (+ 1 (* 2 3)) for 1 + 2 * 3. Every node with children is a list whose head
+// is its kind:
+//
+//	(program s...)  (block s...)  (var name value)  (const name value)  (return value)
+//	(op right)  (op left right)  (if condition consequence alternative)  (call function args...)
+//
+// An expression statement is its expression. Identifiers, integers and booleans
+// are atoms, strings are quoted, and missing nodes are nil
+func Sexp(node Node) string {
+	var out strings.Builder
+	writeSexp(&out, node)
+	return out.String()
+}
+
+func writeSexp(out *strings.Builder, node Node) {
+	list := func(head string, children ...Node) {
+		out.WriteString("(" + head)
+		for _, child := range children {
+			out.WriteString(" ")
+			writeSexp(out, child)
+		}
+		out.WriteString(")")
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		list("program", statementNodes(node.Statements)...)
+	case *BlockStatement:
+		if node == nil {
+			out.WriteString("nil")
+			return
+		}
+		list("block", statementNodes(node.Statements)...)
+	case *VarStatement:
+		list(node.TokenLiteral(), node.Name, node.Value)
+	case *ReturnStatement:
+		list("return", node.ReturnValue)
+	case *ExpressionStatement:
+		writeSexp(out, node.Expression)
+	case *PrefixExpression:
+		list(node.TokenLiteral(), node.Right)
+	case *InfixExpression:
+		list(node.Operator.Literal, node.Left, node.Right)
+	case *IfExpression:
+		if node.Alternative == nil {
+			list("if", node.Condition, node.Consequence)
+		} else {
+			list("if", node.Condition, node.Consequence, node.Alternative)
+		}
+	case *CallExpression:
+		args := []Node{node.Function}
+		for _, a := range node.Arguments {
+			args = append(args, a)
+		}
+		list("call", args...)
+	case *Identifier:
+		out.WriteString(node.Value)
+	case *IntegerLiteral:
+		out.WriteString(strconv.FormatInt(node.Value, 10))
+	case *BooleanExpression:
+		out.WriteString(strconv.FormatBool(node.Value))
+	case *StringLiteral:
+		out.WriteString(strconv.Quote(node.Value))
+	case nil:
+		out.WriteString("nil")
+	default:
+		out.WriteString(node.String())
+	}
+}
+
+func statementNodes(statements []Statement) []Node {
+	nodes := make([]Node, len(statements))
+	for i, s := range statements {
+		nodes[i] = s
+	}
+	return nodes
+}
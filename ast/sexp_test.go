@@ -0,0 +1,56 @@
+package ast_test
+
+import (
+	"blank/ast"
+	"blank/lexer"
+	"blank/parser"
+	"blank/token"
+	"testing"
+)
+
+func TestSexp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "(program (+ 1 (* 2 3)))"},
+		{"(1 + 2) * 3", "(program (* (+ 1 2) 3))"},
+		{"a - b - c", "(program (- (- a b) c))"},
+		{"-a * b", "(program (* (- a) b))"},
+		{"!(true == false)", "(program (! (== true false)))"},
+		{"var x = 5; const s = \"a\\\"b\";", `(program (var x 5) (const s "a\"b"))`},
+		{"return f(1, g(2) + 3);", "(program (return (call f 1 (+ (call g 2) 3))))"},
+		{"if (x < y) { x } else { var z = y; z }", "(program (if (< x y) (block x) (block (var z y) z)))"},
+		{"if (x) {}", "(program (if x (block)))"},
+		{"f()()", "(program (call (call f)))"},
+		{"", "(program)"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, p.Errors())
+		}
+		if got := ast.Sexp(program); got != tt.expected {
+			t.Errorf("input %q: wrong sexp. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestSexpMissingNodes(t *testing.T) {
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{nil, "nil"},
+		{&ast.PrefixExpression{Token: token.Token{Type: token.MINUS, Literal: "-"}}, "(- nil)"},
+		{&ast.ReturnStatement{}, "(return nil)"},
+	}
+
+	for _, tt := range tests {
+		if got := ast.Sexp(tt.node); got != tt.expected {
+			t.Errorf("wrong sexp. expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
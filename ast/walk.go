@@ -0,0 +1,94 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is called by Walk for each node. If the result
+// visitor w is not nil, Walk visits each of the children of node with w,
+// followed by a call of w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree of node in depth-first order: it calls v.Visit(node),
+// then walks each non-nil child of node, in source order, with the visitor it
+// returned. Comments are not nodes and are not visited. Walk panics on node
+// types it does not know, so that a new node type cannot be skipped silently
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	// Statements
+	case *Program:
+		walkStatements(v, n.Statements)
+	case *VarStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkExpression(v, n.Value)
+	case *ReturnStatement:
+		walkExpression(v, n.ReturnValue)
+	case *ExpressionStatement:
+		walkExpression(v, n.Expression)
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+
+	// Expressions
+	case *Identifier, *IntegerLiteral, *StringLiteral, *BooleanExpression:
+		// no children
+	case *PrefixExpression:
+		walkExpression(v, n.Right)
+	case *InfixExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *IfExpression:
+		walkExpression(v, n.Condition)
+		if n.Consequence != nil {
+			Walk(v, n.Consequence)
+		}
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+	case *CallExpression:
+		walkExpression(v, n.Function)
+		for _, arg := range n.Arguments {
+			walkExpression(v, arg)
+		}
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+// walkExpression walks exp, skipping the nil expressions left by parse errors
+func walkExpression(v Visitor, exp Expression) {
+	if exp != nil {
+		Walk(v, exp)
+	}
+}
+
+func walkStatements(v Visitor, statements []Statement) {
+	for _, s := range statements {
+		if s != nil {
+			Walk(v, s)
+		}
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree of node in depth-first order: it calls f(node), and
+// when it returns true, calls Inspect for each of the non-nil children of node,
+// followed by a call of f(nil)
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,44 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestWalkEveryKind walks an empty node of every kind in kinds, the node types
+// Unmarshal knows, so that Walk supports any node type added there
+func TestWalkEveryKind(t *testing.T) {
+	for kind, newNode := range kinds {
+		node := newNode()
+		if name := reflect.TypeOf(node).Elem().Name(); name != kind {
+			t.Errorf("kinds[%q] creates a %s", kind, name)
+		}
+
+		var visited []Node
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: Walk panicked: %v", kind, r)
+				}
+			}()
+			Inspect(node, func(n Node) bool {
+				visited = append(visited, n)
+				return true
+			})
+		}()
+		if len(visited) == 0 || visited[0] != node || visited[len(visited)-1] != nil {
+			t.Errorf("%s: wrong visits. got=%v", kind, visited)
+		}
+	}
+}
+
+func TestWalkUnknownNode(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Walk did not panic on an unknown node type")
+		}
+	}()
+	Inspect(&unknownNode{}, func(Node) bool { return true })
+}
+
+type unknownNode struct{ Identifier }
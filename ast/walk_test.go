@@ -0,0 +1,103 @@
+package ast_test
+
+import (
+	"blank/ast"
+	"blank/lexer"
+	"blank/parser"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const walkSource = `const limit = 10;
+var name = "blank";
+if (!(limit > 5)) {
+	return f(name, true);
+} else {
+	-limit;
+}
+`
+
+func parseWalkSource(t *testing.T) *ast.Program {
+	p := parser.New(lexer.New(walkSource))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program
+}
+
+func kind(n ast.Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+}
+
+func TestInspectOrder(t *testing.T) {
+	var kinds []string
+	ast.Inspect(parseWalkSource(t), func(n ast.Node) bool {
+		if n != nil {
+			kinds = append(kinds, kind(n))
+		}
+		return true
+	})
+
+	expected := []string{
+		"Program",
+		"VarStatement", "Identifier", "IntegerLiteral",
+		"VarStatement", "Identifier", "StringLiteral",
+		"ExpressionStatement", "IfExpression",
+		"PrefixExpression", "InfixExpression", "Identifier", "IntegerLiteral",
+		"BlockStatement", "ReturnStatement", "CallExpression", "Identifier", "Identifier", "BooleanExpression",
+		"BlockStatement", "ExpressionStatement", "PrefixExpression", "Identifier",
+	}
+	if got := strings.Join(kinds, " "); got != strings.Join(expected, " ") {
+		t.Errorf("wrong visit order.\nexpected=%v\ngot=     %v", expected, kinds)
+	}
+}
+
+func TestInspectPrune(t *testing.T) {
+	var identifiers []string
+	ast.Inspect(parseWalkSource(t), func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok {
+			identifiers = append(identifiers, ident.Value)
+		}
+		_, isBlock := n.(*ast.BlockStatement)
+		return !isBlock
+	})
+
+	if got := strings.Join(identifiers, ","); got != "limit,name,limit" {
+		t.Errorf("wrong identifiers outside blocks. got=%q", got)
+	}
+}
+
+// depthVisitor records each node with its depth, checking that every node is closed by a Visit(nil)
+type depthVisitor struct {
+	depth *int
+	lines *[]string
+}
+
+func (v depthVisitor) Visit(n ast.Node) ast.Visitor {
+	if n == nil {
+		*v.depth--
+		return nil
+	}
+	*v.lines = append(*v.lines, strings.Repeat(".", *v.depth)+kind(n))
+	*v.depth++
+	return v
+}
+
+func TestWalkDepth(t *testing.T) {
+	p := parser.New(lexer.New("var x = -y;"))
+	program := p.ParseProgram()
+
+	depth := 0
+	var lines []string
+	ast.Walk(depthVisitor{&depth, &lines}, program)
+
+	expected := "Program .VarStatement ..Identifier ..PrefixExpression ...Identifier"
+	if got := strings.Join(lines, " "); got != expected {
+		t.Errorf("wrong walk.\nexpected=%q\ngot=     %q", expected, got)
+	}
+	if depth != 0 {
+		t.Errorf("Visit(nil) calls do not match the visits. depth=%d", depth)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"blank/diagnostics"
+	"blank/lexer"
+	"blank/parser"
+	"blank/token"
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runParse implements "blank parse [-json] [-trace] file": it prints each statement of the
+// script on its own line, as the parser understood it, or with -json the whole
+// syntax tree in the JSON schema of package ast, for scripts without syntax errors.
+// -trace writes the trace of the parser to stderr
+func runParse(args []string) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: blank parse [-json] [-trace] file")
+		flags.PrintDefaults()
+	}
+	asJSON := flags.Bool("json", false, "print the syntax tree as JSON")
+	trace := flags.Bool("trace", false, "trace the parse functions to stderr")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	path := flags.Arg(0)
+	source, err := readSource(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	p := parser.New(lexer.NewFile(path, source))
+	if *trace {
+		p.SetTrace(os.Stderr)
+	}
+	program := p.ParseProgram()
+	errors := p.Errors()
+	switch {
+	case *asJSON && len(errors) == 0:
+		data, err := json.Marshal(program)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		fmt.Println(string(data))
+	case !*asJSON:
+		for _, s := range program.Statements {
+			fmt.Printf("%s\t%s\n", s.Pos(), s.String())
+		}
+	}
+	if len(errors) != 0 {
+		diagnostics.Fprint(os.Stderr, source, diagnostics.FromParseErrors(errors))
+		return exitError
+	}
+	return exitOK
+}
+
+// runTokens implements "blank tokens file": it prints the tokens of the script, comments
+// included, as it reads them, so that large or piped scripts are not held in memory
+func runTokens(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: blank tokens file")
+		return exitUsage
+	}
+	f, err := openSource(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer f.Close()
+
+	l := lexer.NewReader(args[0], f)
+	l.SetMode(lexer.ScanComments)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	status := exitOK
+	for tok := l.NextToken(); ; tok = l.NextToken() {
+		fmt.Fprintf(out, "%s\t%s\t%q\n", tok.Pos, tok.Type, tok.Literal)
+		if tok.Type == token.ILLEGAL {
+			status = exitError
+		}
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	if err := l.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return status
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"blank/diagnostics"
+	"blank/format"
+	"blank/parser"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// runFmt implements "blank fmt [-w] [-check] [files]": it prints the files, or stdin
+// when there are none, in canonical format. It returns the exit code of the process
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: blank fmt [-w] [-check] [files]")
+		flags.PrintDefaults()
+	}
+	write := flags.Bool("w", false, "write the result to the file instead of stdout")
+	check := flags.Bool("check", false, "only list the files that are not formatted, exiting with 1 if any")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		return formatFile("<stdin>", string(content), false, *check)
+	}
+
+	status := exitOK
+	for _, path := range flags.Args() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = exitError
+			continue
+		}
+		if code := formatFile(path, string(content), *write, *check); code != 0 {
+			status = code
+		}
+	}
+	return status
+}
+
+// formatFile formats the source read from path and prints, writes or checks the result
+func formatFile(path, source string, write, check bool) int {
+	formatted, err := format.Source(path, source)
+	if err != nil {
+		if errors, ok := err.(parser.ErrorList); ok {
+			diagnostics.Fprint(os.Stderr, source, diagnostics.FromParseErrors(errors))
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return exitError
+	}
+
+	switch {
+	case check:
+		if formatted != source {
+			fmt.Println(path)
+			return exitError
+		}
+	case write:
+		if formatted != source {
+			info, err := os.Stat(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitError
+			}
+			if err := os.WriteFile(path, []byte(formatted), info.Mode().Perm()); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitError
+			}
+		}
+	default:
+		fmt.Print(formatted)
+	}
+	return exitOK
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"blank/diagnostics"
+	"blank/evaluator"
+	"blank/lexer"
+	"blank/object"
+	"blank/parser"
+	"blank/resolver"
+	"blank/severity"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runFile parses, resolves and evaluates the script at path with the script
+// arguments args, rendering its diagnostics to stderr. It returns the exit code of the process.
+// The script is parsed as it is read, keeping a copy of its source for the diagnostics
+func runFile(path string, args []string) int {
+	f, err := openSource(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer f.Close()
+
+	var text strings.Builder
+	p := parser.New(lexer.NewReader(path, io.TeeReader(f, &text)))
+	program := p.ParseProgram()
+	source := text.String()
+	if errors := p.Errors(); len(errors) != 0 {
+		diagnostics.Fprint(os.Stderr, source, diagnostics.FromParseErrors(errors))
+		return exitError
+	}
+
+	env := evaluator.NewEnvironment(os.Stdout, args)
+	r := resolver.New()
+	r.Declare(true, env.Declared()...) // the built-ins
+	resolved := diagnostics.FromResolver(r.Resolve(program))
+	diagnostics.Fprint(os.Stderr, source, resolved)
+	for _, d := range resolved {
+		if d.Severity == severity.Error {
+			return exitError
+		}
+	}
+
+	result := evaluator.Eval(program, env)
+	if err, ok := result.(*object.Error); ok {
+		diagnostics.Fprint(os.Stderr, source, []diagnostics.Diagnostic{diagnostics.FromRuntimeError(err)})
+		return exitError
+	}
+	return exitOK
+}
+
+// openSource opens the file at path, or stdin when path is "-"
+func openSource(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// readSource returns the content of the file at path, or of stdin when path is "-"
+func readSource(path string) (string, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	return string(content), err
+}
@@ -0,0 +1,217 @@
+// Package cst builds a lossless concrete syntax tree: the nodes of the
+// AST with every token of the source, each keeping the whitespace and
+// comments around it, so that the exact source can be printed back.
+//
+// The trivia between two tokens is split at the first line break: what
+// comes before it trails the previous token, the rest leads the next one.
+package cst
+
+import (
+	"blank/ast"
+	"blank/lexer"
+	"blank/parser"
+	"blank/token"
+	"reflect"
+	"strings"
+)
+
+type TriviaKind int
+
+const (
+	Whitespace TriviaKind = iota // spaces, tabs and lone carriage returns
+	Newline                      // "\n" or "\r\n"
+	Comment
+	Skipped // other text the lexer ignores, such as a byte order mark
+)
+
+// Trivia is a piece of source between tokens that the AST discards
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+}
+
+// Element is a Node or a Token of the tree
+type Element interface {
+	// String returns the source text of the element, trivia included
+	String() string
+	element()
+}
+
+// Token is a token of the source with its surrounding trivia
+type Token struct {
+	token.Token
+	Text     string // the token as written in the source
+	Leading  []Trivia
+	Trailing []Trivia
+}
+
+func (t *Token) element() {}
+func (t *Token) String() string {
+	var out strings.Builder
+	for _, tr := range t.Leading {
+		out.WriteString(tr.Text)
+	}
+	out.WriteString(t.Text)
+	for _, tr := range t.Trailing {
+		out.WriteString(tr.Text)
+	}
+	return out.String()
+}
+
+// Node is an AST node with, in source order, its child nodes and the tokens that belong to it
+type Node struct {
+	Kind     string // the AST node type, e.g. "VarStatement"
+	AST      ast.Node
+	Children []Element
+}
+
+func (n *Node) element() {}
+func (n *Node) String() string {
+	var out strings.Builder
+	for _, child := range n.Children {
+		out.WriteString(child.String())
+	}
+	return out.String()
+}
+
+// Tokens returns the tokens of the node and of all its descendants, in source order
+func (n *Node) Tokens() []*Token {
+	var tokens []*Token
+	for _, child := range n.Children {
+		switch child := child.(type) {
+		case *Token:
+			tokens = append(tokens, child)
+		case *Node:
+			tokens = append(tokens, child.Tokens()...)
+		}
+	}
+	return tokens
+}
+
+// Parse parses source into a tree whose String() is source, byte for byte.
+// The tree is built even when the source has syntax errors, which are returned
+func Parse(filename, source string) (*Node, parser.ErrorList) {
+	p := parser.New(lexer.NewFile(filename, source))
+	program := p.ParseProgram()
+
+	b := &builder{tokens: tokenize(filename, source)}
+	root := b.node(program, len(source)+1)
+	return root, p.Errors()
+}
+
+type builder struct {
+	tokens []*Token
+	next   int // index of the first token not in the tree yet
+}
+
+// node builds the tree of n, taking the tokens that start before end
+func (b *builder) node(n ast.Node, end int) *Node {
+	tree := &Node{Kind: reflect.TypeOf(n).Elem().Name(), AST: n}
+	for _, child := range children(n) {
+		if !child.Pos().IsValid() {
+			continue
+		}
+		b.take(tree, child.Pos().Offset)
+		tree.Children = append(tree.Children, b.node(child, child.End().Offset))
+	}
+	b.take(tree, end)
+
+	// a statement owns the semicolon that ends it
+	if _, ok := n.(ast.Statement); ok && b.next < len(b.tokens) && b.tokens[b.next].Type == token.SEMICOLON {
+		tree.Children = append(tree.Children, b.tokens[b.next])
+		b.next++
+	}
+	return tree
+}
+
+// take appends to tree the tokens that start before end
+func (b *builder) take(tree *Node, end int) {
+	for b.next < len(b.tokens) && b.tokens[b.next].Pos.Offset < end {
+		tree.Children = append(tree.Children, b.tokens[b.next])
+		b.next++
+	}
+}
+
+// tokenize returns the tokens of source, EOF included, with the trivia between them
+func tokenize(filename, source string) []*Token {
+	l := lexer.NewFile(filename, source)
+	l.SetMode(lexer.ScanComments)
+
+	var tokens []*Token
+	comments := map[int]int{} // comment start offset -> end offset
+	offset := 0
+	var previous *Token
+	for {
+		tok := l.NextToken()
+		if tok.Type == token.COMMENT {
+			comments[tok.Pos.Offset] = tok.End.Offset
+			continue
+		}
+		t := &Token{Token: tok, Text: source[tok.Pos.Offset:tok.End.Offset]}
+		trivia := splitTrivia(source[offset:tok.Pos.Offset], offset, comments)
+		if previous != nil {
+			i := 0
+			for i < len(trivia) && trivia[i].Kind != Newline {
+				i++
+			}
+			previous.Trailing, trivia = trivia[:i], trivia[i:]
+		}
+		t.Leading = trivia
+		tokens = append(tokens, t)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+		previous = t
+		offset = tok.End.Offset
+	}
+}
+
+// splitTrivia splits the text between two tokens, found at offset in the source, into trivia
+func splitTrivia(text string, offset int, comments map[int]int) []Trivia {
+	var trivia []Trivia
+	add := func(kind TriviaKind, s string) {
+		if n := len(trivia); n > 0 && kind != Newline && kind != Comment && trivia[n-1].Kind == kind {
+			trivia[n-1].Text += s
+			return
+		}
+		trivia = append(trivia, Trivia{Kind: kind, Text: s})
+	}
+
+	for i := 0; i < len(text); {
+		if end, ok := comments[offset+i]; ok {
+			add(Comment, text[i:end-offset])
+			i = end - offset
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text[i:], "\r\n"):
+			add(Newline, "\r\n")
+			i += 2
+		case text[i] == '\n':
+			add(Newline, "\n")
+			i++
+		case text[i] == ' ' || text[i] == '\t' || text[i] == '\r':
+			add(Whitespace, text[i:i+1])
+			i++
+		default:
+			add(Skipped, text[i:i+1])
+			i++
+		}
+	}
+	return trivia
+}
+
+// children returns the child nodes of n in source order
+func children(n ast.Node) []ast.Node {
+	var nodes []ast.Node
+	ast.Inspect(n, func(child ast.Node) bool {
+		if child == n {
+			return true
+		}
+		if child != nil {
+			nodes = append(nodes, child)
+		}
+		return false
+	})
+	return nodes
+}
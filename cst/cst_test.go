@@ -0,0 +1,103 @@
+package cst
+
+import (
+	"blank/token"
+	"testing"
+)
+
+func TestParseIsLossless(t *testing.T) {
+	tests := []string{
+		"",
+		"var x = 5;",
+		"\uFEFF/// doc\r\nconst  answer=42 ;  // trailing\r\n\r\n",
+		"var café = \"naïve ☕\";\n\nif (café == \"x\") {\n\treturn -1 * 2; /* nested /* comment */ */\n} else {\n  café\n}\n",
+		"var = 5;\n5 + * 3;\n\"open",
+		"/* unterminated",
+		"x \xff @ \x00 y",
+	}
+
+	for _, input := range tests {
+		tree, _ := Parse("test.blank", input)
+		if tree.String() != input {
+			t.Errorf("tree.String() not the source. expected=%q, got=%q", input, tree.String())
+		}
+	}
+}
+
+func TestParseStructure(t *testing.T) {
+	input := "var x = 1 + 2; // one\n  x;\n"
+	tree, errors := Parse("", input)
+	if len(errors) != 0 {
+		t.Fatalf("parser errors: %v", errors)
+	}
+
+	if tree.Kind != "Program" || len(tree.Children) != 3 {
+		t.Fatalf("root wrong. got=%s with %d children", tree.Kind, len(tree.Children))
+	}
+
+	varStmt := tree.Children[0].(*Node)
+	kinds := []string{}
+	for _, child := range varStmt.Children {
+		switch child := child.(type) {
+		case *Node:
+			kinds = append(kinds, child.Kind)
+		case *Token:
+			kinds = append(kinds, child.Text)
+		}
+	}
+	expected := []string{"var", "Identifier", "=", "InfixExpression", ";"}
+	if len(kinds) != len(expected) {
+		t.Fatalf("VarStatement children wrong. expected=%v, got=%v", expected, kinds)
+	}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Fatalf("VarStatement children wrong. expected=%v, got=%v", expected, kinds)
+		}
+	}
+
+	tokens := tree.Tokens()
+	semicolon := tokens[6]
+	if semicolon.Type != token.SEMICOLON {
+		t.Fatalf("tokens[6] not ;. got=%q", semicolon.Type)
+	}
+	if len(semicolon.Trailing) != 2 || semicolon.Trailing[1].Kind != Comment || semicolon.Trailing[1].Text != "// one" {
+		t.Errorf("trailing trivia of ; wrong. got=%+v", semicolon.Trailing)
+	}
+	x := tokens[7]
+	if len(x.Leading) != 2 || x.Leading[0].Kind != Newline || x.Leading[1].Text != "  " {
+		t.Errorf("leading trivia of x wrong. got=%+v", x.Leading)
+	}
+	eof := tokens[len(tokens)-1]
+	if eof.Type != token.EOF || len(eof.Leading) != 1 || eof.Leading[0].Kind != Newline {
+		t.Errorf("EOF token wrong. got=%+v", eof)
+	}
+}
+
+func TestEditWithoutReformatting(t *testing.T) {
+	input := "var  answer   =  41 ; // keep this layout\n"
+	tree, _ := Parse("", input)
+
+	for _, tok := range tree.Tokens() {
+		if tok.Type == token.INT {
+			tok.Text = "42"
+		}
+	}
+
+	expected := "var  answer   =  42 ; // keep this layout\n"
+	if tree.String() != expected {
+		t.Errorf("edited tree wrong. expected=%q, got=%q", expected, tree.String())
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add("var x = 5; // c\nif (x) { return x; } else { -x }")
+	f.Add("\uFEFF/** doc */ const y = \"☕\";\r\n")
+	f.Add("var = ; ) /* open")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		tree, _ := Parse("", input)
+		if tree.String() != input {
+			t.Fatalf("tree.String() not the source. expected=%q, got=%q", input, tree.String())
+		}
+	})
+}
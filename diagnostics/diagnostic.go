@@ -0,0 +1,108 @@
+package diagnostics
+
+import (
+	"blank/object"
+	"blank/parser"
+	"blank/resolver"
+	"blank/severity"
+	"blank/token"
+)
+
+// Diagnostic is a message about a span of the source, ready to be rendered
+type Diagnostic struct {
+	Severity severity.Level
+	Code     string // empty for runtime errors, which have no code
+	Message  string
+	Pos      token.Pos
+	End      token.Pos // may be invalid, then only the character at Pos is underlined
+	Notes    []string
+	Help     string
+}
+
+// FromParseErrors converts the errors of the parser
+func FromParseErrors(errors parser.ErrorList) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0, len(errors))
+	for _, err := range errors {
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: err.Severity,
+			Code:     err.Code,
+			Message:  err.Msg,
+			Pos:      err.Pos,
+			End:      err.End,
+		})
+	}
+	return diagnostics
+}
+
+// FromResolver converts the diagnostics of the resolver, suggesting a keyword
+// for undefined names that look like a misspelled one
+func FromResolver(resolved []resolver.Diagnostic) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0, len(resolved))
+	for _, r := range resolved {
+		d := Diagnostic{
+			Severity: r.Severity,
+			Code:     r.Code,
+			Message:  r.Message,
+			Pos:      r.Token.Pos,
+			End:      r.Token.End,
+		}
+		if r.Code == resolver.UndefinedName {
+			if keyword := Suggest(r.Token.Literal, token.Keywords()); keyword != "" {
+				d.Help = "did you mean `" + keyword + "`?"
+			}
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// FromRuntimeError converts an error raised by the evaluator
+func FromRuntimeError(err *object.Error) Diagnostic {
+	return Diagnostic{Severity: severity.Error, Message: err.Message, Pos: err.Pos}
+}
+
+// Suggest returns the candidate closest to name by edit distance,
+// or "" when none of them is close enough to be a likely typo
+func Suggest(name string, candidates []string) string {
+	maxDistance := len(name) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+	best := ""
+	for _, candidate := range candidates {
+		distance := editDistance(name, candidate)
+		if distance == 0 || distance > maxDistance {
+			continue
+		}
+		if best == "" || distance < editDistance(name, best) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and
+// transpositions of adjacent characters needed to turn a into b
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
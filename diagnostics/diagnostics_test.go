@@ -0,0 +1,122 @@
+package diagnostics
+
+import (
+	"blank/lexer"
+	"blank/parser"
+	"blank/resolver"
+	"blank/severity"
+	"blank/token"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderParseError(t *testing.T) {
+	source := "var x = 1;\nvar = 5;"
+	p := parser.New(lexer.NewFile("main.blank", source))
+	p.ParseProgram()
+
+	var out bytes.Buffer
+	Fprint(&out, source, FromParseErrors(p.Errors()))
+
+	expected := `error[P001]: expected next token to be IDENT, got = instead
+ --> main.blank:2:5
+  |
+2 | var = 5;
+  |     ^
+`
+	if out.String() != expected {
+		t.Errorf("wrong rendering. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestRenderResolverDiagnosticWithHelp(t *testing.T) {
+	source := "var x = 1;\n\tretrun x;"
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	var out bytes.Buffer
+	for _, d := range FromResolver(resolver.Resolve(program)) {
+		Render(&out, source, d, false)
+	}
+
+	expected := "error[R001]: undefined: retrun\n" +
+		" --> 2:2\n" +
+		"  |\n" +
+		"2 | \tretrun x;\n" +
+		"  | \t^^^^^^\n" +
+		"  = help: did you mean `return`?\n"
+	if out.String() != expected {
+		t.Errorf("wrong rendering. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestRenderNotesAndColor(t *testing.T) {
+	d := Diagnostic{
+		Severity: severity.Warning,
+		Code:     "R004",
+		Message:  "x declared and not used",
+		Pos:      token.Pos{Line: 1, Column: 5, Offset: 4},
+		End:      token.Pos{Line: 1, Column: 6, Offset: 5},
+		Notes:    []string{"remove the declaration or use x"},
+	}
+
+	var plain bytes.Buffer
+	Render(&plain, "var x = 1;", d, false)
+	if !strings.Contains(plain.String(), "  = note: remove the declaration or use x\n") {
+		t.Errorf("note missing. got=\n%s", plain.String())
+	}
+	if strings.Contains(plain.String(), "\x1b[") {
+		t.Errorf("plain rendering contains escape codes. got=%q", plain.String())
+	}
+
+	var colored bytes.Buffer
+	Render(&colored, "var x = 1;", d, true)
+	if !strings.Contains(colored.String(), colorYellow+"warning[R004]"+colorReset) {
+		t.Errorf("colored rendering missing warning color. got=%q", colored.String())
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"retrun", "return"},
+		{"esle", "else"},
+		{"tru", "true"},
+		{"fucn", "func"},
+		{"x", ""},
+		{"foobar", ""},
+		{"return", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Suggest(tt.name, token.Keywords()); got != tt.expected {
+			t.Errorf("Suggest(%q) wrong. expected=%q, got=%q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestRenderUnicodeColumns(t *testing.T) {
+	source := "var café = \"☕\";\ncafé + naïve;"
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+
+	var out bytes.Buffer
+	for _, d := range FromResolver(resolver.Resolve(program)) {
+		Render(&out, source, d, false)
+	}
+
+	expected := "error[R001]: undefined: naïve\n" +
+		" --> 2:8\n" +
+		"  |\n" +
+		"2 | café + naïve;\n" +
+		"  |        ^^^^^\n"
+	if out.String() != expected {
+		t.Errorf("wrong rendering. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,132 @@
+package diagnostics
+
+import (
+	"blank/severity"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[1;31m"
+	colorYellow = "\x1b[1;33m"
+	colorBlue   = "\x1b[1;34m"
+	colorCyan   = "\x1b[1;36m"
+)
+
+// Fprint renders every diagnostic about source to w, in color when w is a terminal
+func Fprint(w io.Writer, source string, diagnostics []Diagnostic) {
+	color := IsTerminal(w)
+	for _, d := range diagnostics {
+		Render(w, source, d, color)
+	}
+}
+
+// Render writes d to w with the source line it refers to and a caret under the span:
+//
+//	error[R001]: undefined: retrun
+//	 --> main.blank:1:1
+//	  |
+//	1 | retrun 5;
+//	  | ^^^^^^
+//	  = help: did you mean `return`?
+func Render(w io.Writer, source string, d Diagnostic, color bool) {
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + colorReset
+	}
+
+	severityColor := colorRed
+	if d.Severity == severity.Warning {
+		severityColor = colorYellow
+	}
+	header := d.Severity.String()
+	if d.Code != "" {
+		header += "[" + d.Code + "]"
+	}
+	fmt.Fprintf(w, "%s%s\n", paint(severityColor, header), paint(colorBold, ": "+d.Message))
+
+	if !d.Pos.IsValid() {
+		renderFooter(w, "", d, paint)
+		return
+	}
+
+	lineNumber := strconv.Itoa(d.Pos.Line)
+	gutter := strings.Repeat(" ", len(lineNumber))
+	line := sourceLine(source, d.Pos.Line)
+
+	fmt.Fprintf(w, "%s%s %s\n", gutter, paint(colorBlue, "-->"), d.Pos)
+	fmt.Fprintf(w, "%s\n", paint(colorBlue, gutter+" |"))
+	fmt.Fprintf(w, "%s %s\n", paint(colorBlue, lineNumber+" |"), line)
+	fmt.Fprintf(w, "%s %s%s\n", paint(colorBlue, gutter+" |"),
+		padding(line, d.Pos.Column), paint(severityColor, strings.Repeat("^", spanWidth(line, d))))
+	renderFooter(w, gutter, d, paint)
+}
+
+func renderFooter(w io.Writer, gutter string, d Diagnostic, paint func(code, s string) string) {
+	for _, note := range d.Notes {
+		fmt.Fprintf(w, "%s %s note: %s\n", gutter, paint(colorBlue, "="), note)
+	}
+	if d.Help != "" {
+		fmt.Fprintf(w, "%s %s %s\n", gutter, paint(colorBlue, "="), paint(colorCyan, "help: ")+d.Help)
+	}
+}
+
+// IsTerminal reports whether w is a terminal that should get colored output.
+// Setting the NO_COLOR environment variable disables colors
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// HELPERS
+
+// sourceLine returns the line with the given number, starting at 1, without its line break
+func sourceLine(source string, number int) string {
+	lines := strings.Split(source, "\n")
+	if number < 1 || number > len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[number-1], "\r")
+}
+
+// padding returns the blanks that align a caret under column, counted in runes,
+// keeping the tabs of the line so the caret lines up however tabs are displayed
+func padding(line string, column int) string {
+	runes := []rune(line)
+	var out strings.Builder
+	for i := 0; i < column-1; i++ {
+		if i < len(runes) && runes[i] == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+	}
+	return out.String()
+}
+
+// spanWidth returns the number of carets under the span of d, at least one
+func spanWidth(line string, d Diagnostic) int {
+	if !d.End.IsValid() || d.End.Line != d.Pos.Line || d.End.Column <= d.Pos.Column {
+		return 1
+	}
+	end := d.End.Column
+	if length := utf8.RuneCountInString(line); end-1 > length {
+		end = length + 1
+	}
+	if end <= d.Pos.Column {
+		return 1
+	}
+	return end - d.Pos.Column
+}
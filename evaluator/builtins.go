@@ -0,0 +1,64 @@
+package evaluator
+
+import (
+	"blank/object"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Builtins returns the built-in functions of a program that prints to out and
+// was started with the script arguments args:
+//
+//	puts(x, ...)  prints each argument on its own line
+//	len(s)        the number of characters of a string
+//	argc()        the number of script arguments
+//	arg(i)        the script argument i, starting at 0
+func Builtins(out io.Writer, args []string) []*object.Builtin {
+	return []*object.Builtin{
+		{Name: "puts", Fn: func(values ...object.Object) object.Object {
+			for _, v := range values {
+				fmt.Fprintln(out, v.Inspect())
+			}
+			return NULL
+		}},
+		{Name: "len", Fn: func(values ...object.Object) object.Object {
+			if len(values) != 1 {
+				return newError("wrong number of arguments to len: got=%d, want=1", len(values))
+			}
+			s, ok := values[0].(*object.String)
+			if !ok {
+				return newError("argument to len not supported, got %s", values[0].Type())
+			}
+			return &object.Integer{Value: int64(utf8.RuneCountInString(s.Value))}
+		}},
+		{Name: "argc", Fn: func(values ...object.Object) object.Object {
+			if len(values) != 0 {
+				return newError("wrong number of arguments to argc: got=%d, want=0", len(values))
+			}
+			return &object.Integer{Value: int64(len(args))}
+		}},
+		{Name: "arg", Fn: func(values ...object.Object) object.Object {
+			if len(values) != 1 {
+				return newError("wrong number of arguments to arg: got=%d, want=1", len(values))
+			}
+			i, ok := values[0].(*object.Integer)
+			if !ok {
+				return newError("argument to arg must be INTEGER, got %s", values[0].Type())
+			}
+			if i.Value < 0 || i.Value >= int64(len(args)) {
+				return newError("script argument %d out of range, there are %d", i.Value, len(args))
+			}
+			return &object.String{Value: args[i.Value]}
+		}},
+	}
+}
+
+// NewEnvironment returns a top level environment with the built-in functions declared as constants
+func NewEnvironment(out io.Writer, args []string) *object.Environment {
+	env := object.NewEnvironment()
+	for _, b := range Builtins(out, args) {
+		env.Declare(b.Name, b, true)
+	}
+	return env
+}
@@ -0,0 +1,366 @@
+package evaluator
+
+import (
+	"blank/ast"
+	"blank/lexer"
+	"blank/object"
+	"blank/parser"
+	"blank/resolver"
+	"bytes"
+	"testing"
+)
+
+func TestEvalIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"!true", false},
+		{"!!5", true},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"true == false", false},
+		{"1 < 2 == true", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalStringExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello World!"`, "Hello World!"},
+		{`"café" + " " + "☕"`, "café ☕"},
+		{`var π = "pi"; π;`, "pi"},
+		{`"a" == "a"`, true},
+		{`"a" != "a"`, false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+			}
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if integer, ok := tt.expected.(int); ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else if evaluated != NULL {
+			t.Errorf("object is not NULL. got=%T (%+v)", evaluated, evaluated)
+		}
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"return 10; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"if (true) { if (true) { return 10; } return 1; }", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBlockScoping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"var x = 1; if (true) { var x = 2; } x;", 1},
+		{"var x = 1; if (true) { var y = x + 1; y; }", 2},
+		{"var x = 1; if (true) { var x = 2; x; }", 2},
+		{"const x = 1; if (true) { var x = 2; x; }", 2},
+		{"var x = 1; if (true) { if (true) { x + 10; } }", 11},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalResolvedIdentifiers(t *testing.T) {
+	input := "var x = 1; var y = 2; if (true) { var x = 10; if (true) { x + y; } }"
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	resolver.Resolve(program)
+	testIntegerObject(t, Eval(program, object.NewEnvironment()), 12)
+}
+
+func TestEvalResolvedBuiltins(t *testing.T) {
+	input := `var s = "ab"; len(s) + argc()`
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	env := NewEnvironment(&bytes.Buffer{}, []string{"x"})
+	r := resolver.New()
+	r.Declare(true, env.Declared()...)
+	if diagnostics := r.Resolve(program); len(diagnostics) != 0 {
+		t.Fatalf("resolver diagnostics: %v", diagnostics)
+	}
+
+	sum := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.InfixExpression)
+	length := sum.Left.(*ast.CallExpression).Function.(*ast.Identifier)
+	if slot := length.Resolution.Slot; env.Declared()[slot] != "len" {
+		t.Errorf("len resolved to slot %d, which holds %s", slot, env.Declared()[slot])
+	}
+	testIntegerObject(t, Eval(program, env), 3)
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) { true + false; 5; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "identifier not found: foobar"},
+		{"if (true) { var y = 1; } y;", "identifier not found: y"},
+		{"var x = 1; var x = 2;", "x is already declared in this scope"},
+		{"10 / 0", "division by zero"},
+		{`"a" - "b"`, "unknown operator: STRING - STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var x = 1;\nx + y;", "ERROR: 2:5: identifier not found: y"},
+		{"if (true) {\n  -true; }", "ERROR: 2:3: unknown operator: -BOOLEAN"},
+		{"var x = 1; var x = 2;", "ERROR: 1:16: x is already declared in this scope"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("input %q: expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestConstReassignmentAcrossPrograms(t *testing.T) {
+	env := object.NewEnvironment()
+	for _, input := range []string{"const x = 1;", "var x = 2;"} {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", input, p.Errors())
+		}
+		result := Eval(program, env)
+		if input == "const x = 1;" {
+			continue
+		}
+		errObj, ok := result.(*object.Error)
+		if !ok {
+			t.Fatalf("no error object returned. got=%T(%+v)", result, result)
+		}
+		if errObj.Message != "cannot reassign constant x" {
+			t.Errorf("wrong error message. got=%q", errObj.Message)
+		}
+	}
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("café")`, 4},
+		{`len(1)`, "argument to len not supported, got INTEGER"},
+		{`len("a", "b")`, "wrong number of arguments to len: got=2, want=1"},
+		{`argc()`, 2},
+		{`arg(1)`, "second"},
+		{`arg(2)`, "script argument 2 out of range, there are 2"},
+		{`arg("0")`, "argument to arg must be INTEGER, got STRING"},
+		{`puts("a", 1 + 1, true)`, nil},
+		{`var n = 1; n(2)`, "not a function: INTEGER"},
+		{`len(arg(-1))`, "script argument -1 out of range, there are 2"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, p.Errors())
+		}
+		evaluated := Eval(program, NewEnvironment(&out, []string{"first", "second"}))
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			if evaluated != NULL {
+				t.Errorf("input %q: object is not NULL. got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+			if out.String() != "a\n2\ntrue\n" {
+				t.Errorf("input %q: wrong output. got=%q", tt.input, out.String())
+			}
+		case string:
+			if s, ok := evaluated.(*object.String); ok {
+				if s.Value != expected {
+					t.Errorf("input %q: wrong string. expected=%q, got=%q", tt.input, expected, s.Value)
+				}
+				continue
+			}
+			err, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("input %q: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if err.Message != expected {
+				t.Errorf("input %q: wrong error message. expected=%q, got=%q", tt.input, expected, err.Message)
+			}
+		}
+	}
+}
+
+func TestValuelessBlocks(t *testing.T) {
+	tests := []struct {
+		input          string
+		expected       string
+		expectedOutput string
+	}{
+		{"if (true) {}", "null", ""},
+		{"if (true) { var z = 1; }", "null", ""},
+		{"var y = if (true) { var z = 1; }; y", "null", ""},
+		{"-if (true) {};", "ERROR: 1:1: unknown operator: -NULL", ""},
+		{"!if (true) {};", "true", ""},
+		{"var y = if (true) { var z = 1; }; y + 1", "ERROR: 1:35: type mismatch: NULL + INTEGER", ""},
+		{"if (true) {} == if (false) { 1 }", "true", ""},
+		{"puts(if (true) {})", "null", "null\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, p.Errors())
+		}
+		evaluated := Eval(program, NewEnvironment(&out, nil))
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("input %q: expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+		if out.String() != tt.expectedOutput {
+			t.Errorf("input %q: wrong output. expected=%q, got=%q", tt.input, tt.expectedOutput, out.String())
+		}
+	}
+}
+
+func testEval(t *testing.T, input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return Eval(program, object.NewEnvironment())
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+		return false
+	}
+	return true
+}
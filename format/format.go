@@ -0,0 +1,233 @@
+// Package format prints Blank programs in their canonical layout: one
+// statement per line, blocks indented with tabs, single spaces around
+// infix operators, only the parentheses the precedence of the operators
+// requires, at most one blank line between statements, and comments kept.
+package format
+
+import (
+	"blank/ast"
+	"blank/lexer"
+	"blank/parser"
+	"blank/token"
+	"strings"
+)
+
+// Source formats the source of a program. Sources with syntax errors are
+// not formatted, their errors are returned instead
+func Source(filename, source string) (string, error) {
+	p := parser.New(lexer.NewFile(filename, source))
+	program := p.ParseProgram()
+	if err := p.Errors().Err(); err != nil {
+		return "", err
+	}
+	return shebang(source) + Program(program), nil
+}
+
+// shebang returns the interpreter line at the start of source, newline included, if it has one
+func shebang(source string) string {
+	if !strings.HasPrefix(source, "#!") {
+		return ""
+	}
+	if i := strings.IndexByte(source, '\n'); i >= 0 {
+		return source[:i+1]
+	}
+	return source + "\n"
+}
+
+// Program returns the canonical source of program, comments included
+func Program(program *ast.Program) string {
+	p := &printer{comments: program.Comments}
+	p.statements(program.Statements, token.Pos{Offset: -1})
+	p.commentsBefore(token.Pos{Offset: -1})
+	return p.out.String()
+}
+
+type printer struct {
+	out      strings.Builder
+	indent   int
+	comments []*ast.CommentGroup
+	next     int // index of the first comment group not printed yet
+	lastLine int // source line of the last thing printed, 0 before the first one
+}
+
+// statements prints statements, which are followed by what starts at end in the source
+func (p *printer) statements(statements []ast.Statement, end token.Pos) {
+	for i, s := range statements {
+		next := end
+		if i+1 < len(statements) {
+			next = statements[i+1].Pos()
+		}
+		p.commentsBefore(s.Pos())
+		p.line(s.Pos().Line)
+		p.statement(s, i+1 < len(statements) && continuesExpression(statements[i+1]))
+		p.trailingComments(s.End().Line, next)
+		p.out.WriteString("\n")
+		p.lastLine = s.End().Line
+	}
+}
+
+// statement prints s. An if expression statement only ends with a semicolon when the next
+// statement starts with a token that would otherwise continue the if, as set by semicolon
+func (p *printer) statement(s ast.Statement, semicolon bool) {
+	switch s := s.(type) {
+	case *ast.VarStatement:
+		p.out.WriteString(s.TokenLiteral() + " " + s.Name.Value + " = ")
+		p.expression(s.Value, parser.LOWEST)
+		p.out.WriteString(";")
+	case *ast.ReturnStatement:
+		p.out.WriteString("return ")
+		p.expression(s.ReturnValue, parser.LOWEST)
+		p.out.WriteString(";")
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression, parser.LOWEST)
+		if _, ok := s.Expression.(*ast.IfExpression); !ok || semicolon {
+			p.out.WriteString(";")
+		}
+	case *ast.BlockStatement:
+		p.block(s)
+	}
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	if len(b.Statements) == 0 && !p.hasCommentsBefore(b.End()) {
+		p.out.WriteString("{}")
+		return
+	}
+	p.out.WriteString("{")
+	first := b.Rbrace.Pos
+	if len(b.Statements) > 0 {
+		first = b.Statements[0].Pos()
+	}
+	p.trailingComments(b.Pos().Line, first)
+	p.out.WriteString("\n")
+	p.lastLine = 0 // no blank line at the start of a block
+	p.indent++
+	p.statements(b.Statements, b.Rbrace.Pos)
+	p.commentsBefore(b.Rbrace.Pos)
+	p.indent--
+	p.writeIndent()
+	p.out.WriteString("}")
+}
+
+// continuesExpression reports whether s is printed starting with a token that can also
+// continue the expression of the statement before it, such as the - of a negation
+func continuesExpression(s ast.Statement) bool {
+	es, ok := s.(*ast.ExpressionStatement)
+	return ok && parser.Precedence(firstToken(es.Expression, parser.LOWEST)) > parser.LOWEST
+}
+
+// firstToken returns the type of the first token expression prints for exp at precedence,
+// or an empty type when it does not matter
+func firstToken(exp ast.Expression, precedence int) token.TokenType {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		own := parser.Precedence(exp.Operator.Type)
+		if own < precedence {
+			return token.LPAREN
+		}
+		return firstToken(exp.Left, own)
+	case *ast.PrefixExpression:
+		if parser.PREFIX < precedence {
+			return token.LPAREN
+		}
+		return exp.Token.Type
+	case *ast.CallExpression:
+		return firstToken(exp.Function, parser.CALL)
+	}
+	return ""
+}
+
+// expression prints exp, between parentheses when its operator binds weaker than
+// precedence, the precedence the position of exp requires
+func (p *printer) expression(exp ast.Expression, precedence int) {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		own := parser.Precedence(exp.Operator.Type)
+		if own < precedence {
+			p.out.WriteString("(")
+			defer p.out.WriteString(")")
+		}
+		// operators are left associative, so a right operand of the same precedence needs parentheses
+		p.expression(exp.Left, own)
+		p.out.WriteString(" " + exp.Operator.Literal + " ")
+		p.expression(exp.Right, own+1)
+	case *ast.PrefixExpression:
+		// a prefix expression binds weaker than a call, so it needs parentheses as the function of one
+		if parser.PREFIX < precedence {
+			p.out.WriteString("(")
+			defer p.out.WriteString(")")
+		}
+		p.out.WriteString(exp.Token.Literal)
+		p.expression(exp.Right, parser.PREFIX)
+	case *ast.CallExpression:
+		p.expression(exp.Function, parser.CALL)
+		p.out.WriteString("(")
+		for i, arg := range exp.Arguments {
+			if i > 0 {
+				p.out.WriteString(", ")
+			}
+			p.expression(arg, parser.LOWEST)
+		}
+		p.out.WriteString(")")
+	case *ast.IfExpression:
+		p.out.WriteString("if (")
+		p.expression(exp.Condition, parser.LOWEST)
+		p.out.WriteString(") ")
+		p.block(exp.Consequence)
+		if exp.Alternative != nil {
+			p.out.WriteString(" else ")
+			p.block(exp.Alternative)
+		}
+	case nil:
+	default:
+		p.out.WriteString(exp.String())
+	}
+}
+
+// commentsBefore prints, each on its own line, the comment groups that start before pos.
+// An invalid offset prints all the remaining ones
+func (p *printer) commentsBefore(pos token.Pos) {
+	for ; p.next < len(p.comments); p.next++ {
+		group := p.comments[p.next]
+		if pos.Offset >= 0 && group.Pos().Offset >= pos.Offset {
+			return
+		}
+		for _, c := range group.List {
+			p.line(c.Pos().Line)
+			p.out.WriteString(c.Token.Literal)
+			p.out.WriteString("\n")
+			p.lastLine = c.End().Line
+		}
+	}
+}
+
+// hasCommentsBefore reports whether a comment group not printed yet starts before pos
+func (p *printer) hasCommentsBefore(pos token.Pos) bool {
+	return p.next < len(p.comments) && p.comments[p.next].Pos().Offset < pos.Offset
+}
+
+// trailingComments prints the comment groups that start on line after what was printed
+// and end before the next thing to print, at before. An invalid offset does not limit them
+func (p *printer) trailingComments(line int, before token.Pos) {
+	for ; p.next < len(p.comments) && p.comments[p.next].Pos().Line == line; p.next++ {
+		if before.Offset >= 0 && p.comments[p.next].End().Offset > before.Offset {
+			return
+		}
+		for _, c := range p.comments[p.next].List {
+			p.out.WriteString(" " + c.Token.Literal)
+		}
+	}
+}
+
+// line starts a line for something found on the given source line,
+// keeping one blank line if the source had any since the last thing printed
+func (p *printer) line(line int) {
+	if p.lastLine > 0 && line > p.lastLine+1 {
+		p.out.WriteString("\n")
+	}
+	p.writeIndent()
+}
+
+func (p *printer) writeIndent() {
+	p.out.WriteString(strings.Repeat("\t", p.indent))
+}
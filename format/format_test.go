@@ -0,0 +1,134 @@
+package format
+
+import (
+	"blank/ast"
+	"blank/lexer"
+	"blank/parser"
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var x=5", "var x = 5;\n"},
+		{"var   a = 1;var b=a+2*3;b", "var a = 1;\nvar b = a + 2 * 3;\nb;\n"},
+		{"((1 + 2)) * 3; 1 + (2 * 3); 1 - (2 - 3); (1 - 2) - 3; -(-x); !(a == b)",
+			"(1 + 2) * 3;\n1 + 2 * 3;\n1 - (2 - 3);\n1 - 2 - 3;\n--x;\n!(a == b);\n"},
+		{"if(x<y){return x;}else{y}", "if (x < y) {\n\treturn x;\n} else {\n\ty;\n}\n"},
+		{"if (a) { if (b) { 1 } }", "if (a) {\n\tif (b) {\n\t\t1;\n\t}\n}\n"},
+		{"if (a) {}", "if (a) {}\n"},
+		{"var s = \"tab\\t \\\"quoted\\\" café\";", "var s = \"tab\\t \\\"quoted\\\" café\";\n"},
+		{"var a = 1;\n\n\n\nvar b = 2;\nvar c = 3;", "var a = 1;\n\nvar b = 2;\nvar c = 3;\n"},
+		{
+			"// header\n\n/// the answer\nconst answer = 42; // trailing\nif (answer) { // open\n\t// inside\n   answer\n\n  /* before close */\n}\n// footer\n",
+			"// header\n\n/// the answer\nconst answer = 42; // trailing\nif (answer) { // open\n\t// inside\n\tanswer;\n\n\t/* before close */\n}\n// footer\n",
+		},
+		{"if (a) {\n\n\n  1;\n}", "if (a) {\n\t1;\n}\n"},
+		{"if (a) {\n  // only a comment\n}", "if (a) {\n\t// only a comment\n}\n"},
+		{"puts( a,b*(c+d) ,f(  ) )", "puts(a, b * (c + d), f());\n"},
+		{"#!/usr/bin/env blank\nvar x=1", "#!/usr/bin/env blank\nvar x = 1;\n"},
+		{"(-a)(b); (!f)(); -a(b)", "(-a)(b);\n(!f)();\n-a(b);\n"},
+		{"if (x) { 1 } // note", "if (x) {\n\t1;\n} // note\n"},
+		{"if (x) { 1 } else { /* c */ }", "if (x) {\n\t1;\n} else { /* c */\n}\n"},
+		{"if (x) { /* a */ 1 /* b */ } /* c */ 2; // d", "if (x) { /* a */\n\t1; /* b */\n} /* c */\n2; // d\n"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := Source("", tt.input)
+		if err != nil {
+			t.Errorf("input %q: unexpected error: %s", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("input %q: wrong format.\nexpected=%q\ngot=     %q", tt.input, tt.expected, got)
+			continue
+		}
+		again, err := Source("", got)
+		if err != nil || again != got {
+			t.Errorf("input %q: formatting is not idempotent. got=%q (%v)", tt.input, again, err)
+		}
+	}
+}
+
+func TestSourceKeepsMeaning(t *testing.T) {
+	inputs := []string{
+		"-(1 + 2) * (3 - -4) / (5 * (6 / 2)) < 8 == !(true != false)",
+		"1 - (2 - (3 - 4)) + (1 + 2) + 3 * (4 * 5)",
+		"if (if (a) { b } else { c }) { -(if (x) { 1 }) }",
+		"(-a)(b) + (!f)(1)(2)",
+		"(if (x) { f } else { g })(1, -(-a)(b))",
+		"-(if (x) { f })(1)",
+		"(-(if (x) { f }))(1)",
+		"if (x) { 1 }; -1",
+		"if (a) { 1 } else { 2 }; -1;",
+		"if (x) { f }; (1)",
+		"if (x) { f }\n(1)",
+		"if (x) { 1 }; !x",
+	}
+
+	for _, input := range inputs {
+		formatted, err := Source("", input)
+		if err != nil {
+			t.Fatalf("input %q: unexpected error: %s", input, err)
+		}
+		if expected, got := parse(t, input), parse(t, formatted); expected != got {
+			t.Errorf("input %q: formatted %q parses differently.\nexpected=%q\ngot=     %q", input, formatted, expected, got)
+		}
+		if again, err := Source("", formatted); err != nil || again != formatted {
+			t.Errorf("input %q: formatting is not idempotent. got=%q (%v)", input, again, err)
+		}
+	}
+}
+
+func TestSourceSyntaxError(t *testing.T) {
+	_, err := Source("main.blank", "var = 5;")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if _, ok := err.(parser.ErrorList); !ok {
+		t.Errorf("error not parser.ErrorList. got=%T", err)
+	}
+}
+
+// parse returns the program in input with every prefix and infix expression
+// parenthesized, so that two programs with the same tree give the same string
+func parse(t *testing.T, input string) string {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	var out strings.Builder
+	for _, s := range program.Statements {
+		out.WriteString(shape(s.(*ast.ExpressionStatement).Expression) + ";")
+	}
+	return out.String()
+}
+
+func shape(exp ast.Expression) string {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		return "(" + shape(exp.Left) + " " + exp.Operator.Literal + " " + shape(exp.Right) + ")"
+	case *ast.PrefixExpression:
+		return "(" + exp.Token.Literal + shape(exp.Right) + ")"
+	case *ast.IfExpression:
+		out := "if " + shape(exp.Condition) + " { " + shapeBlock(exp.Consequence) + " }"
+		if exp.Alternative != nil {
+			out += " else { " + shapeBlock(exp.Alternative) + " }"
+		}
+		return out
+	}
+	return exp.String()
+}
+
+func shapeBlock(block *ast.BlockStatement) string {
+	var out strings.Builder
+	for _, s := range block.Statements {
+		out.WriteString(shape(s.(*ast.ExpressionStatement).Expression) + ";")
+	}
+	return out.String()
+}
@@ -1,33 +1,156 @@
+// Package lexer turns Blank source code into tokens.
+//
+// The source is UTF-8 encoded text. A byte order mark at its start is ignored,
+// and token columns count runes, not bytes. A first line starting with #!, the
+// interpreter line of an executable script, is ignored too.
+//
+// Identifiers start with a Unicode letter or an underscore, followed by any
+// number of Unicode letters, underscores and Unicode decimal digits:
+//
+//	identifier = letter { letter | digit }
+//	letter     = unicode_letter | "_"
+//	digit      = unicode_digit
+//
+// so "café", "π" and "x1" are identifiers. Integer literals only use the ASCII digits 0-9.
+//
+// Comments are either line comments, from // to the end of the line, or block
+// comments between /* and */, which may be nested. They are skipped like
+// whitespace, unless the lexer is in ScanComments mode.
+//
+// The source is read as a stream, one rune ahead, so memory use does not
+// grow with the size of the input.
 package lexer
 
-import "blank/token"
+import (
+	"blank/token"
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// eof is the value of ch once the whole input was read
+const eof = -1
+
+// bom is the byte order mark some editors write at the start of UTF-8 files
+const bom = '\uFEFF'
+
+// Mode controls optional behavior of the lexer
+type Mode uint
+
+const (
+	// ScanComments makes the lexer return comments as token.COMMENT tokens
+	ScanComments Mode = 1 << iota
+)
 
 type Lexer struct {
-	input        string
-	position     int
-	readPosition int
-	ch           byte
+	reader   io.RuneReader
+	mode     Mode
+	filename string
+	err      error // first error returned by reader, other than io.EOF
+	position int   // byte offset of ch
+	ch       rune
+	chSize   int
+	next     rune // the rune after ch, read ahead for peekChar
+	nextSize int
+	line     int
+	column   int
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewFile("", input)
+}
+
+// NewFile returns a lexer whose token positions refer to filename
+func NewFile(filename, input string) *Lexer {
+	return NewReader(filename, strings.NewReader(input))
+}
+
+// NewReader returns a lexer reading the source from r as it goes, whose token
+// positions refer to filename. Readers that are not an io.RuneReader are buffered
+func NewReader(filename string, r io.Reader) *Lexer {
+	rr, ok := r.(io.RuneReader)
+	if !ok {
+		rr = bufio.NewReader(r)
+	}
+	l := &Lexer{reader: rr, filename: filename, line: 1}
+	l.next, l.nextSize = l.readRune()
 	l.readChar()
+	if l.ch == bom {
+		l.column = 0
+		l.readChar()
+	}
+	if l.ch == '#' && l.next == '!' {
+		l.skipLine()
+	}
 	return l
 }
 
+// skipLine moves to the newline ending the current line, or to the end of the input
+func (l *Lexer) skipLine() {
+	for l.ch != '\n' && l.ch != eof {
+		l.readChar()
+	}
+}
+
+// Err returns the first error met while reading the source, other than io.EOF.
+// The lexer treats such an error as the end of the input
+func (l *Lexer) Err() error {
+	return l.err
+}
+
+// Mode returns the mode of the lexer
+func (l *Lexer) Mode() Mode {
+	return l.mode
+}
+
+// SetMode changes the mode of the lexer, from the next token on
+func (l *Lexer) SetMode(mode Mode) {
+	l.mode = mode
+}
+
+// readChar moves to the next rune of the input.
+// Invalid UTF-8 bytes are read one at a time as utf8.RuneError
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
-	} else {
-		l.ch = l.input[l.readPosition]
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.position += l.chSize
+	l.ch, l.chSize = l.next, l.nextSize
+	if l.ch != eof {
+		l.next, l.nextSize = l.readRune()
 	}
-	l.position = l.readPosition
-	l.readPosition += 1
+	l.column++
 }
 
+// readRune decodes a rune from the reader, returning eof at the end of the input
+func (l *Lexer) readRune() (rune, int) {
+	ch, size, err := l.reader.ReadRune()
+	if err != nil {
+		if err != io.EOF && l.err == nil {
+			l.err = err
+		}
+		return eof, 0
+	}
+	return ch, size
+}
+
+// NextToken returns the next token of the input, skipping comments
+// unless the lexer is in ScanComments mode
 func (l *Lexer) NextToken() token.Token {
+	for {
+		tok := l.scanToken()
+		if tok.Type != token.COMMENT || l.mode&ScanComments != 0 {
+			return tok
+		}
+	}
+}
+
+func (l *Lexer) scanToken() token.Token {
 	var tok token.Token
 	l.skipWhitespace()
+	pos := l.pos()
 
 	switch l.ch {
 	case '=':
@@ -45,6 +168,11 @@ func (l *Lexer) NextToken() token.Token {
 	case '-':
 		tok = newToken(token.MINUS, l.ch)
 	case '/':
+		if l.peekChar() == '/' || l.peekChar() == '*' {
+			tok = l.readComment()
+			tok.Pos, tok.End = pos, l.pos()
+			return tok
+		}
 		tok = newToken(token.SLASH, l.ch)
 	case '<':
 		tok = newToken(token.LT, l.ch)
@@ -70,17 +198,25 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
-	case 0:
+	case '"':
+		tok = l.readString()
+		tok.Pos, tok.End = pos, l.pos()
+		return tok
+	case eof:
 		tok.Literal = ""
 		tok.Type = token.EOF
+		tok.Pos, tok.End = pos, pos
+		return tok
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos, tok.End = pos, l.pos()
 			return tok
 		} else if isDigit(l.ch) {
 			tok.Type = token.INT
 			tok.Literal = l.readNumber()
+			tok.Pos, tok.End = pos, l.pos()
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
@@ -88,22 +224,111 @@ func (l *Lexer) NextToken() token.Token {
 	}
 
 	l.readChar()
+	tok.Pos, tok.End = pos, l.pos()
 	return tok
 }
 
+// pos returns the position of the current character
+func (l *Lexer) pos() token.Pos {
+	return token.Pos{Filename: l.filename, Line: l.line, Column: l.column, Offset: l.position}
+}
+
 func (l *Lexer) readIdentifier() string {
-	position := l.position
-	for isLetter(l.ch) {
+	var out strings.Builder
+	for isLetter(l.ch) || unicode.IsDigit(l.ch) {
+		out.WriteRune(l.ch)
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	return out.String()
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// readString reads a double quoted string, with the escapes \n, \t, \r, \" and \\.
+// The literal of the token is the decoded content, without the quotes.
+// A string missing its closing quote is returned as an ILLEGAL token with the raw text read
+func (l *Lexer) readString() token.Token {
+	var out, raw strings.Builder
+	raw.WriteRune(l.ch)
+	for {
+		l.readChar()
+		if l.ch != eof {
+			raw.WriteRune(l.ch)
+		}
+		switch l.ch {
+		case '"':
+			l.readChar()
+			return token.Token{Type: token.STRING, Literal: out.String()}
+		case eof:
+			return token.Token{Type: token.ILLEGAL, Literal: raw.String()}
+		case '\\':
+			l.readChar()
+			if l.ch != eof {
+				raw.WriteRune(l.ch)
+			}
+			switch l.ch {
+			case 'n':
+				out.WriteRune('\n')
+			case 't':
+				out.WriteRune('\t')
+			case 'r':
+				out.WriteRune('\r')
+			case '"', '\\':
+				out.WriteRune(l.ch)
+			case eof:
+				return token.Token{Type: token.ILLEGAL, Literal: raw.String()}
+			default:
+				out.WriteRune('\\')
+				out.WriteRune(l.ch)
+			}
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+// readComment reads a line comment or a, possibly nested, block comment.
+// The literal of the token is the whole comment, delimiters included.
+// A block comment missing its closing */ is returned as an ILLEGAL token
+func (l *Lexer) readComment() token.Token {
+	var out strings.Builder
+	out.WriteRune(l.ch)
+	l.readChar()
+
+	if l.ch == '/' {
+		for l.ch != '\n' && l.ch != eof {
+			out.WriteRune(l.ch)
+			l.readChar()
+		}
+		return token.Token{Type: token.COMMENT, Literal: strings.TrimSuffix(out.String(), "\r")}
+	}
+
+	out.WriteRune(l.ch)
+	l.readChar()
+	depth := 1
+	for depth > 0 {
+		switch {
+		case l.ch == eof:
+			return token.Token{Type: token.ILLEGAL, Literal: out.String()}
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			out.WriteString("*/")
+			l.readChar()
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			out.WriteString("/*")
+			l.readChar()
+		default:
+			out.WriteRune(l.ch)
+		}
+		l.readChar()
+	}
+	return token.Token{Type: token.COMMENT, Literal: out.String()}
+}
+
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
@@ -114,21 +339,18 @@ func (l *Lexer) skipWhitespace() {
 }
 
 func (l *Lexer) readNumber() string {
-	position := l.position
+	var out strings.Builder
 	for isDigit(l.ch) {
+		out.WriteRune(l.ch)
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	return out.String()
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
-		return 0
-	} else {
-		return l.input[l.readPosition]
-	}
+func (l *Lexer) peekChar() rune {
+	return l.next
 }
@@ -1,7 +1,11 @@
 package lexer
 
 import (
+	"errors"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"blank/token"
 )
@@ -14,7 +18,7 @@ func TestNextTokn(t *testing.T) {
 			x + y;
 		};
 		var result = add(five, ten);
-		!-/*5;
+		!-/ *5;
 		5 < 10 > 5;
 
 		if (5 < 10) {
@@ -121,3 +125,291 @@ func TestNextTokn(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "var x = 10;\n  x == y;"
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Pos
+		expectedEnd  token.Pos
+	}{
+		{token.VAR, token.Pos{Filename: "test.blank", Line: 1, Column: 1, Offset: 0}, token.Pos{Filename: "test.blank", Line: 1, Column: 4, Offset: 3}},
+		{token.IDENT, token.Pos{Filename: "test.blank", Line: 1, Column: 5, Offset: 4}, token.Pos{Filename: "test.blank", Line: 1, Column: 6, Offset: 5}},
+		{token.ASSIGN, token.Pos{Filename: "test.blank", Line: 1, Column: 7, Offset: 6}, token.Pos{Filename: "test.blank", Line: 1, Column: 8, Offset: 7}},
+		{token.INT, token.Pos{Filename: "test.blank", Line: 1, Column: 9, Offset: 8}, token.Pos{Filename: "test.blank", Line: 1, Column: 11, Offset: 10}},
+		{token.SEMICOLON, token.Pos{Filename: "test.blank", Line: 1, Column: 11, Offset: 10}, token.Pos{Filename: "test.blank", Line: 1, Column: 12, Offset: 11}},
+		{token.IDENT, token.Pos{Filename: "test.blank", Line: 2, Column: 3, Offset: 14}, token.Pos{Filename: "test.blank", Line: 2, Column: 4, Offset: 15}},
+		{token.EQ, token.Pos{Filename: "test.blank", Line: 2, Column: 5, Offset: 16}, token.Pos{Filename: "test.blank", Line: 2, Column: 7, Offset: 18}},
+		{token.IDENT, token.Pos{Filename: "test.blank", Line: 2, Column: 8, Offset: 19}, token.Pos{Filename: "test.blank", Line: 2, Column: 9, Offset: 20}},
+		{token.SEMICOLON, token.Pos{Filename: "test.blank", Line: 2, Column: 9, Offset: 20}, token.Pos{Filename: "test.blank", Line: 2, Column: 10, Offset: 21}},
+		{token.EOF, token.Pos{Filename: "test.blank", Line: 2, Column: 10, Offset: 21}, token.Pos{Filename: "test.blank", Line: 2, Column: 10, Offset: 21}},
+	}
+
+	l := NewFile("test.blank", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - pos wrong. expected=%+v, got=%+v",
+				i, tt.expectedPos, tok.Pos)
+		}
+		if tok.End != tt.expectedEnd {
+			t.Errorf("tests[%d] - end wrong. expected=%+v, got=%+v",
+				i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "\uFEFFvar café = \"naïve ☕\";\nπ_2 + x1;\n\"a\\\"b\\n\" \"open"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.VAR, "var", 1, 1},
+		{token.IDENT, "café", 1, 5},
+		{token.ASSIGN, "=", 1, 10},
+		{token.STRING, "naïve ☕", 1, 12},
+		{token.SEMICOLON, ";", 1, 21},
+		{token.IDENT, "π_2", 2, 1},
+		{token.PLUS, "+", 2, 5},
+		{token.IDENT, "x1", 2, 7},
+		{token.SEMICOLON, ";", 2, 9},
+		{token.STRING, "a\"b\n", 3, 1},
+		{token.ILLEGAL, "\"open", 3, 10},
+		{token.EOF, "", 3, 15},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - position wrong. expected=%d:%d, got=%s",
+				i, tt.expectedLine, tt.expectedColumn, tok.Pos)
+		}
+	}
+}
+
+func TestIllegalInput(t *testing.T) {
+	input := "x \xff @ \x00"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"},
+		{token.ILLEGAL, "�"},
+		{token.ILLEGAL, "@"},
+		{token.ILLEGAL, "\x00"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func FuzzNextToken(f *testing.F) {
+	f.Add("var five = 5;\nif (five < 10) { return true; } else { return false; }")
+	f.Add("\uFEFFvar café = \"naïve ☕\";")
+	f.Add("\"unterminated \\")
+	f.Add("\xff\xfe\x00 π")
+	f.Add("// line\n/* /* nested */ */ /* open")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		l := New(input)
+		last := token.Pos{Line: 1, Column: 1}
+		// every token consumes at least one byte, so more tokens than bytes means the lexer is stuck
+		for i := 0; i <= len(input)+1; i++ {
+			tok := l.NextToken()
+			if tok.Pos.Offset < last.Offset || tok.End.Offset < tok.Pos.Offset || tok.End.Offset > len(input) {
+				t.Fatalf("token %q has invalid span %+v - %+v after %+v", tok.Literal, tok.Pos, tok.End, last)
+			}
+			last = tok.End
+			if tok.Type == token.EOF {
+				return
+			}
+		}
+		t.Fatalf("no EOF token after %d tokens", len(input)+2)
+	})
+}
+
+func TestNewReaderMatchesNew(t *testing.T) {
+	input := "\uFEFFvar café = \"naïve ☕\";\nif (x1 != 10) { return -x1 * 2; } \"open \xff"
+
+	fromString := New(input)
+	fromReader := NewReader("", iotest.OneByteReader(strings.NewReader(input)))
+
+	for i := 0; ; i++ {
+		expected := fromString.NextToken()
+		tok := fromReader.NextToken()
+		if tok != expected {
+			t.Fatalf("tokens[%d] differ. expected=%+v, got=%+v", i, expected, tok)
+		}
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	if fromReader.Err() != nil {
+		t.Errorf("fromReader.Err() not nil. got=%s", fromReader.Err())
+	}
+}
+
+// repeatReader yields line over and over until size bytes were read
+type repeatReader struct {
+	line string
+	size int
+	read int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.read >= r.size {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && r.read < r.size {
+		p[n] = r.line[r.read%len(r.line)]
+		n++
+		r.read++
+	}
+	return n, nil
+}
+
+func TestNewReaderLargeInput(t *testing.T) {
+	line := "var x1 = 12345;\n"
+	lines := 1 << 19 // 8MB of source
+	l := NewReader("generated.blank", &repeatReader{line: line, size: len(line) * lines})
+
+	tokens := 0
+	var last token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tokens++
+		last = tok
+	}
+
+	if tokens != 5*lines {
+		t.Errorf("wrong number of tokens. expected=%d, got=%d", 5*lines, tokens)
+	}
+	expected := token.Pos{Filename: "generated.blank", Line: lines, Column: 15, Offset: len(line)*lines - 2}
+	if last.Type != token.SEMICOLON || last.Pos != expected {
+		t.Errorf("last token wrong. expected=; at %+v, got=%+v", expected, last)
+	}
+}
+
+func TestNewReaderError(t *testing.T) {
+	readErr := errors.New("disk on fire")
+	l := NewReader("", io.MultiReader(strings.NewReader("var x"), iotest.ErrReader(readErr)))
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+	if l.Err() != readErr {
+		t.Errorf("l.Err() wrong. expected=%v, got=%v", readErr, l.Err())
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+var x = 10; // trailing comment
+/* block /* nested */ still comment */ x / 2;
+/**/
+`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		comment         bool // only returned in ScanComments mode
+	}{
+		{token.COMMENT, "// leading comment", true},
+		{token.VAR, "var", false},
+		{token.IDENT, "x", false},
+		{token.ASSIGN, "=", false},
+		{token.INT, "10", false},
+		{token.SEMICOLON, ";", false},
+		{token.COMMENT, "// trailing comment", true},
+		{token.COMMENT, "/* block /* nested */ still comment */", true},
+		{token.IDENT, "x", false},
+		{token.SLASH, "/", false},
+		{token.INT, "2", false},
+		{token.SEMICOLON, ";", false},
+		{token.COMMENT, "/**/", true},
+		{token.EOF, "", false},
+	}
+
+	for _, mode := range []Mode{0, ScanComments} {
+		l := New(input)
+		l.SetMode(mode)
+		for i, tt := range tests {
+			if tt.comment && mode&ScanComments == 0 {
+				continue
+			}
+			tok := l.NextToken()
+			if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+				t.Fatalf("mode %d, tests[%d] - token wrong. expected=%q %q, got=%q %q",
+					mode, i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+			}
+		}
+	}
+}
+
+func TestShebang(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType token.TokenType
+		line, column int
+	}{
+		{"#!/usr/bin/env blank\nvar", token.VAR, 2, 1},
+		{"\uFEFF#!/usr/bin/env blank\r\nvar", token.VAR, 2, 1},
+		{"#!/usr/bin/env blank", token.EOF, 1, 21},
+		{"var x;\n#!not a shebang", token.VAR, 1, 1},
+	}
+
+	for _, tt := range tests {
+		tok := New(tt.input).NextToken()
+		if tok.Type != tt.expectedType || tok.Pos.Line != tt.line || tok.Pos.Column != tt.column {
+			t.Errorf("input %q: first token wrong. expected=%q at %d:%d, got=%q at %s",
+				tt.input, tt.expectedType, tt.line, tt.column, tok.Type, tok.Pos)
+		}
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	l := New("x /* open /* nested */")
+
+	tok := l.NextToken()
+	if tok.Type != token.IDENT {
+		t.Fatalf("first token not IDENT. got=%q", tok.Type)
+	}
+	tok = l.NextToken()
+	if tok.Type != token.ILLEGAL || tok.Literal != "/* open /* nested */" {
+		t.Fatalf("unterminated comment wrong. got=%q %q", tok.Type, tok.Literal)
+	}
+	if tok.Pos.Column != 3 || tok.End.Column != 23 {
+		t.Errorf("unterminated comment span wrong. got=%s - %s", tok.Pos, tok.End)
+	}
+	if tok = l.NextToken(); tok.Type != token.EOF {
+		t.Errorf("last token not EOF. got=%q", tok.Type)
+	}
+}
@@ -0,0 +1,69 @@
+package lineedit
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// History returns the lines of the history, the oldest first
+func (e *Editor) History() []string {
+	return e.history
+}
+
+// AddHistory adds line to the history, unless it is blank or the same as the last one.
+// After LoadHistory the line is also appended to the history file
+func (e *Editor) AddHistory(line string) error {
+	if strings.TrimSpace(line) == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return nil
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > MaxHistory {
+		e.history = e.history[len(e.history)-MaxHistory:]
+	}
+	if e.historyFile == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(e.historyFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadHistory reads the history from the file at path, one line per entry, and
+// keeps the history in it from then on. A missing file is an empty history
+func (e *Editor) LoadHistory(path string) error {
+	e.historyFile = path
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(lines) > MaxHistory {
+		// keep the file from growing forever
+		lines = lines[len(lines)-MaxHistory:]
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+			return err
+		}
+	}
+	e.history = append(e.history, lines...)
+	return nil
+}
@@ -0,0 +1,365 @@
+// Package lineedit reads lines from a terminal with editing, history and
+// completion, in the spirit of readline.
+//
+// The supported keys are:
+//
+//	Left, Right, Ctrl-B, Ctrl-F      move the cursor
+//	Home, End, Ctrl-A, Ctrl-E        move to the start or the end of the line
+//	Backspace, Delete, Ctrl-D        delete a character
+//	Ctrl-K, Ctrl-U, Ctrl-W           delete to the end, to the start, the word before the cursor
+//	Up, Down, Ctrl-P, Ctrl-N         browse the history
+//	Ctrl-R                           search the history backwards, Ctrl-G cancels
+//	Tab                              complete the word before the cursor
+//	Ctrl-L                           clear the screen
+//	Ctrl-C                           abandon the line
+//	Ctrl-D on an empty line          end of input
+//
+// The terminal is in raw mode only while ReadLine runs.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// MaxHistory is the number of lines the history keeps
+const MaxHistory = 1000
+
+// ErrInterrupted is returned by ReadLine when the user abandons the line with Ctrl-C
+var ErrInterrupted = errors.New("interrupted")
+
+// Editor reads lines, with editing, from a terminal
+type Editor struct {
+	// Complete returns the words that complete prefix, the word before the cursor
+	Complete func(prefix string) []string
+
+	in          *bufio.Reader
+	out         io.Writer
+	fd          int // file descriptor of the terminal, -1 when in is not one
+	history     []string
+	historyFile string
+
+	// state of the line being read
+	prompt string
+	buf    []rune
+	pos    int // index of the cursor in buf
+}
+
+// New returns an editor reading keys from in and echoing the line to out.
+// When in is a terminal it is put in raw mode while reading a line; any
+// other reader is read as a sequence of keys, which is mostly useful for tests
+func New(in io.Reader, out io.Writer) *Editor {
+	e := &Editor{in: bufio.NewReader(in), out: out, fd: -1}
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		e.fd = int(f.Fd())
+	}
+	return e
+}
+
+// IsTerminal reports whether r is a terminal an Editor can read lines from
+func IsTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && isTerminal(int(f.Fd()))
+}
+
+// ReadLine prints prompt and returns the line the user entered, without its line
+// break. It returns io.EOF at the end of the input and ErrInterrupted on Ctrl-C
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if e.fd >= 0 {
+		restore, err := makeRaw(e.fd)
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	e.prompt, e.buf, e.pos = prompt, nil, 0
+	index := len(e.history) // history entry shown, len(e.history) for the new line
+	current := ""           // the new line, saved while browsing the history
+	e.refresh()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(e.buf) > 0 {
+				e.write("\r\n")
+				return string(e.buf), nil
+			}
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			e.write("\r\n")
+			return string(e.buf), nil
+		case ctrl('C'):
+			e.write("^C\r\n")
+			return "", ErrInterrupted
+		case ctrl('D'):
+			if len(e.buf) == 0 {
+				e.write("\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.pos, e.pos+1)
+		case ctrl('A'):
+			e.pos = 0
+		case ctrl('E'):
+			e.pos = len(e.buf)
+		case ctrl('B'):
+			e.moveTo(e.pos - 1)
+		case ctrl('F'):
+			e.moveTo(e.pos + 1)
+		case ctrl('H'), 127:
+			if e.pos > 0 {
+				e.delete(e.pos-1, e.pos)
+			}
+		case ctrl('K'):
+			e.delete(e.pos, len(e.buf))
+		case ctrl('U'):
+			e.delete(0, e.pos)
+		case ctrl('W'):
+			e.delete(e.wordStart(), e.pos)
+		case ctrl('L'):
+			e.write("\x1b[H\x1b[2J")
+		case ctrl('P'), ctrl('N'):
+			index, current = e.browse(r == ctrl('P'), index, current)
+		case ctrl('R'):
+			if line, done := e.search(); done {
+				return line, nil
+			}
+		case '\t':
+			e.complete()
+		case '\x1b':
+			switch e.escape() {
+			case 'A':
+				index, current = e.browse(true, index, current)
+			case 'B':
+				index, current = e.browse(false, index, current)
+			case 'C':
+				e.moveTo(e.pos + 1)
+			case 'D':
+				e.moveTo(e.pos - 1)
+			case 'H':
+				e.pos = 0
+			case 'F':
+				e.pos = len(e.buf)
+			case '3':
+				e.delete(e.pos, e.pos+1)
+			}
+		default:
+			if unicode.IsPrint(r) {
+				e.insert(string(r))
+			}
+		}
+		e.refresh()
+	}
+}
+
+// ctrl returns the character the terminal sends for Ctrl and key
+func ctrl(key rune) rune {
+	return key & 0x1f
+}
+
+// escape reads the rest of an escape sequence and returns the key it stands for:
+// 'A' to 'D' for the arrows, 'H' and 'F' for Home and End, '3' for Delete, 0 for the others
+func (e *Editor) escape() rune {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+	var params []rune
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return 0
+		}
+		if !(r >= '0' && r <= '9' || r == ';') {
+			break
+		}
+		params = append(params, r)
+	}
+	if r != '~' {
+		return r
+	}
+	switch string(params) {
+	case "1", "7":
+		return 'H'
+	case "4", "8":
+		return 'F'
+	case "3":
+		return '3'
+	}
+	return 0
+}
+
+func (e *Editor) insert(s string) {
+	runes := []rune(s)
+	e.buf = append(e.buf[:e.pos], append(runes, e.buf[e.pos:]...)...)
+	e.pos += len(runes)
+}
+
+// delete removes the runes of buf from start to end, the cursor moving to start
+func (e *Editor) delete(start, end int) {
+	if end > len(e.buf) {
+		end = len(e.buf)
+	}
+	if start >= end {
+		return
+	}
+	e.buf = append(e.buf[:start], e.buf[end:]...)
+	e.pos = start
+}
+
+func (e *Editor) moveTo(pos int) {
+	if pos >= 0 && pos <= len(e.buf) {
+		e.pos = pos
+	}
+}
+
+// wordStart returns the index in buf where the word before the cursor starts
+func (e *Editor) wordStart() int {
+	start := e.pos
+	for start > 0 && isWordRune(e.buf[start-1]) {
+		start--
+	}
+	if start == e.pos {
+		// no word right before the cursor: skip the spaces and the word before them
+		for start > 0 && unicode.IsSpace(e.buf[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(e.buf[start-1]) {
+			start--
+		}
+	}
+	return start
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// browse shows the previous or the next history entry, returning the new index
+// and the new line typed before browsing
+func (e *Editor) browse(previous bool, index int, current string) (int, string) {
+	if index == len(e.history) {
+		current = string(e.buf)
+	}
+	if previous && index > 0 {
+		index--
+	} else if !previous && index < len(e.history) {
+		index++
+	} else {
+		return index, current
+	}
+	if index == len(e.history) {
+		e.buf = []rune(current)
+	} else {
+		e.buf = []rune(e.history[index])
+	}
+	e.pos = len(e.buf)
+	return index, current
+}
+
+// search lets the user search the history backwards for the lines containing a query.
+// Enter returns the match with done set, Ctrl-G and Ctrl-C restore the line, and any
+// other key leaves the match in the line and then edits it as usual
+func (e *Editor) search() (line string, done bool) {
+	query := ""
+	match := len(e.history)
+	find := func(from int) {
+		for i := from; i >= 0; i-- {
+			if i < len(e.history) && strings.Contains(e.history[i], query) {
+				match = i
+				return
+			}
+		}
+	}
+	found := func() string {
+		if match < len(e.history) {
+			return e.history[match]
+		}
+		return ""
+	}
+
+	for {
+		e.write(fmt.Sprintf("\r(reverse-i-search)`%s': %s\x1b[K", query, found()))
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", false
+		}
+		switch {
+		case r == '\r' || r == '\n':
+			e.write("\r\n")
+			return found(), true
+		case r == ctrl('G') || r == ctrl('C'):
+			return "", false
+		case r == ctrl('R'):
+			find(match - 1)
+		case r == ctrl('H') || r == 127:
+			if query != "" {
+				query = string([]rune(query)[:len([]rune(query))-1])
+				match = len(e.history)
+				find(match - 1)
+			}
+		case unicode.IsPrint(r):
+			query += string(r)
+			find(match)
+		default:
+			if match < len(e.history) {
+				e.buf = []rune(e.history[match])
+				e.pos = len(e.buf)
+			}
+			e.in.UnreadRune()
+			return "", false
+		}
+	}
+}
+
+// complete completes the word before the cursor: with the only completion, with
+// the prefix all the completions share, or else by listing them
+func (e *Editor) complete() {
+	if e.Complete == nil {
+		return
+	}
+	start := e.pos
+	for start > 0 && isWordRune(e.buf[start-1]) {
+		start--
+	}
+	prefix := string(e.buf[start:e.pos])
+	words := e.Complete(prefix)
+	if len(words) == 0 {
+		e.write("\a")
+		return
+	}
+
+	common := []rune(words[0])
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, string(common)) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(common) > len([]rune(prefix)) {
+		e.insert(strings.TrimPrefix(string(common), prefix))
+		return
+	}
+	if len(words) > 1 {
+		e.write("\r\n" + strings.Join(words, "  ") + "\r\n")
+	}
+}
+
+// refresh redraws the line, the cursor at its place
+func (e *Editor) refresh() {
+	line := "\r" + e.prompt + string(e.buf) + "\x1b[K"
+	if back := len(e.buf) - e.pos; back > 0 {
+		line += fmt.Sprintf("\x1b[%dD", back)
+	}
+	e.write(line)
+}
+
+func (e *Editor) write(s string) {
+	io.WriteString(e.out, s)
+}
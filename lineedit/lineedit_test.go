@@ -0,0 +1,163 @@
+package lineedit
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadLine(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"hello\r", "hello"},
+		{"helo\x1b[Dl\r", "hello"},
+		{"world\x01hello \r", "hello world"},
+		{"hello\x1b[H\x1b[3~\x1b[F!\r", "ello!"},
+		{"abc\x7f\x7fd\r", "ad"},
+		{"var x = 1\x17\x172\r", "var x 2"},
+		{"one two\x02\x02\x02\x0b\r", "one "},
+		{"one two\x02\x02\x15\r", "wo"},
+		{"ab\x02\x02\x06\x04\r", "a"},
+		{"cafX\x7fé\x1b[D\x1b[C!\r", "café!"},
+		{"partial", "partial"},
+	}
+
+	for _, tt := range tests {
+		e := New(strings.NewReader(tt.keys), io.Discard)
+		line, err := e.ReadLine("> ")
+		if err != nil {
+			t.Errorf("keys %q: unexpected error: %s", tt.keys, err)
+			continue
+		}
+		if line != tt.expected {
+			t.Errorf("keys %q: wrong line. expected=%q, got=%q", tt.keys, tt.expected, line)
+		}
+	}
+}
+
+func TestReadLineEndings(t *testing.T) {
+	e := New(strings.NewReader("abc\x03\x04"), io.Discard)
+	if _, err := e.ReadLine("> "); err != ErrInterrupted {
+		t.Errorf("Ctrl-C error wrong. got=%v", err)
+	}
+	if _, err := e.ReadLine("> "); err != io.EOF {
+		t.Errorf("Ctrl-D error wrong. got=%v", err)
+	}
+	if _, err := e.ReadLine("> "); err != io.EOF {
+		t.Errorf("end of input error wrong. got=%v", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"\x1b[A\r", "var b = 2;"},
+		{"\x1b[A\x1b[A\x1b[A\x1b[A\r", "var a = 1;"},
+		{"new\x1b[A\x1b[B\r", "new"},
+		{"\x10\x10\x0e\r", "var b = 2;"},
+		{"\x121;\r", "var a = 1;"},
+		{"\x12var\x12\r", "var a = 1;"},
+		{"\x12b\x1b[D!\r", "var b = 2!;"},
+		{"x\x12b\x07\r", "x"},
+	}
+
+	for _, tt := range tests {
+		e := New(strings.NewReader(tt.keys), io.Discard)
+		e.AddHistory("var a = 1;")
+		e.AddHistory("a + 1")
+		e.AddHistory("var b = 2;")
+		line, err := e.ReadLine("> ")
+		if err != nil {
+			t.Errorf("keys %q: unexpected error: %s", tt.keys, err)
+			continue
+		}
+		if line != tt.expected {
+			t.Errorf("keys %q: wrong line. expected=%q, got=%q", tt.keys, tt.expected, line)
+		}
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	e := New(strings.NewReader(""), io.Discard)
+	if err := e.LoadHistory(path); err != nil {
+		t.Fatalf("missing history file: %s", err)
+	}
+	for _, line := range []string{"1", "", "2", "2", "  ", "3"} {
+		if err := e.AddHistory(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e = New(strings.NewReader(""), io.Discard)
+	if err := e.LoadHistory(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(e.History(), ","); got != "1,2,3" {
+		t.Errorf("wrong history. expected=%q, got=%q", "1,2,3", got)
+	}
+
+	long := strings.Repeat("line\n", MaxHistory+10)
+	if err := os.WriteFile(path, []byte(long), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e = New(strings.NewReader(""), io.Discard)
+	if err := e.LoadHistory(path); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(path)
+	if len(e.History()) != MaxHistory || strings.Count(string(content), "\n") != MaxHistory {
+		t.Errorf("history not trimmed. got=%d entries, %d lines in file",
+			len(e.History()), strings.Count(string(content), "\n"))
+	}
+}
+
+func TestComplete(t *testing.T) {
+	words := []string{"puts", "return", "result", "résumé"}
+	complete := func(prefix string) []string {
+		var matches []string
+		for _, w := range words {
+			if strings.HasPrefix(w, prefix) {
+				matches = append(matches, w)
+			}
+		}
+		return matches
+	}
+
+	tests := []struct {
+		keys     string
+		expected string
+		listed   bool
+	}{
+		{"pu\t(1)\r", "puts(1)", false},
+		{"x + ret\t\r", "x + return", false},
+		{"re\t\r", "re", true},
+		{"rés\t\r", "résumé", false},
+		{"zz\t\r", "zz", false},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		e := New(strings.NewReader(tt.keys), &out)
+		e.Complete = complete
+		line, err := e.ReadLine("> ")
+		if err != nil {
+			t.Errorf("keys %q: unexpected error: %s", tt.keys, err)
+			continue
+		}
+		if line != tt.expected {
+			t.Errorf("keys %q: wrong line. expected=%q, got=%q", tt.keys, tt.expected, line)
+		}
+		if listed := strings.Contains(out.String(), "return  result"); listed != tt.listed {
+			t.Errorf("keys %q: completions listed=%t, expected=%t", tt.keys, listed, tt.listed)
+		}
+	}
+}
@@ -1,17 +1,81 @@
 package main
 
 import (
+	"blank/lineedit"
 	"blank/repl"
+	"flag"
 	"fmt"
 	"os"
 	"os/user"
 )
 
+// Exit codes of the blank command
+const (
+	exitOK    = 0
+	exitError = 1 // the script failed to parse, resolve or run
+	exitUsage = 2
+)
+
+const usage = `usage:
+	blank run file [args...]   run a script, "-" reads it from stdin
+	blank file [args...]       same as run, for #!/usr/bin/env blank scripts
+	blank repl [-q]            start the interactive shell, -q without greeting
+	blank fmt [-w] [-check] [files]
+	blank parse [-json] [-trace] file
+	                           print the syntax tree of a script
+	blank tokens file          print the tokens of a script
+`
+
 func main() {
-	user, err := user.Current()
-	if err != nil {
-		panic(err)
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches the command line args to the subcommands and returns the exit code of the process
+func run(args []string) int {
+	if len(args) == 0 {
+		return runRepl(nil)
+	}
+	switch args[0] {
+	case "run":
+		if len(args) < 2 {
+			fmt.Fprint(os.Stderr, usage)
+			return exitUsage
+		}
+		return runFile(args[1], args[2:])
+	case "repl":
+		return runRepl(args[1:])
+	case "fmt":
+		return runFmt(args[1:])
+	case "parse":
+		return runParse(args[1:])
+	case "tokens":
+		return runTokens(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return exitOK
+	}
+	return runFile(args[0], args[1:])
+}
+
+// runRepl implements "blank repl [-q]". The greeting is only written to a terminal, and -q leaves it out
+func runRepl(args []string) int {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	quiet := flags.Bool("q", false, "do not print the greeting")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	config := repl.Config{}
+	if !*quiet && lineedit.IsTerminal(os.Stdin) {
+		config.Greeting = greeting()
+	}
+	repl.Start(os.Stdin, os.Stdout, config)
+	return exitOK
+}
+
+func greeting() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return fmt.Sprintf("Blank Lang: Welcome %s!", u.Username)
 	}
-	fmt.Printf("Blank Lang: Welcome %s! \n", user.Username)
-	repl.Start(os.Stdin, os.Stdout)
+	return "Blank Lang: Welcome!"
 }
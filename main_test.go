@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCaptured runs the command line args and returns the exit code and what was written to stdout and stderr
+func runCaptured(t *testing.T, args ...string) (int, string, string) {
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout, os.Stderr = outFile, errFile
+	code := run(args)
+
+	read := func(f *os.File) string {
+		f.Seek(0, io.SeekStart)
+		data, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		return string(data)
+	}
+	return code, read(outFile), read(errFile)
+}
+
+func TestParseInvalidScript(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-;", "no prefix parse function for ; found"},
+		{"1 + ;", "no prefix parse function for ; found"},
+		{"@ + 1;", `illegal character "@"`},
+		{"if (x) { -; } else { 1 + }", "no prefix parse function for ; found"},
+		{"var = 1;", "expected next token to be IDENT, got = instead"},
+		{"return 1 + ;", "no prefix parse function for ; found"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "script.blank")
+		if err := os.WriteFile(path, []byte(tt.input), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		code, _, stderr := runCaptured(t, "parse", path)
+		if code != exitError {
+			t.Errorf("input %q: wrong exit code. expected=%d, got=%d", tt.input, exitError, code)
+		}
+		if !strings.Contains(stderr, tt.expected) {
+			t.Errorf("input %q: error %q not reported. got=%q", tt.input, tt.expected, stderr)
+		}
+	}
+}
+
+// withStdin makes input the content of stdin until the end of the test
+func withStdin(t *testing.T, input string) {
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		f.Close()
+	})
+}
+
+func TestRunFromStdin(t *testing.T) {
+	withStdin(t, "var x = 1;\nputs(x + arg(0));\nputs(y);\n")
+	code, stdout, stderr := runCaptured(t, "run", "-", "2")
+	if code != exitError {
+		t.Errorf("wrong exit code. expected=%d, got=%d", exitError, code)
+	}
+	if stdout != "" {
+		t.Errorf("script ran despite the undefined name. got=%q", stdout)
+	}
+	// the diagnostic quotes the source, which was read from stdin as it was parsed
+	if !strings.Contains(stderr, "undefined: y") || !strings.Contains(stderr, "3 | puts(y);") {
+		t.Errorf("wrong diagnostics. got=%q", stderr)
+	}
+}
+
+func TestTokensFromStdin(t *testing.T) {
+	withStdin(t, "var a = 1; // c\n")
+	code, stdout, _ := runCaptured(t, "tokens", "-")
+	if code != exitOK {
+		t.Errorf("wrong exit code. expected=%d, got=%d", exitOK, code)
+	}
+	expected := "-:1:1\tVAR\t\"var\"\n-:1:5\tIDENT\t\"a\"\n-:1:7\t=\t\"=\"\n-:1:9\tINT\t\"1\"\n" +
+		"-:1:10\t;\t\";\"\n-:1:12\tCOMMENT\t\"// c\"\n-:2:1\tEOF\t\"\"\n"
+	if stdout != expected {
+		t.Errorf("wrong tokens.\nexpected=%q\ngot=     %q", expected, stdout)
+	}
+}
@@ -0,0 +1,108 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+)
+
+type binding struct {
+	name     string
+	value    Object
+	constant bool
+}
+
+// Environment holds the bindings of one lexical scope. Lookups that miss
+// in the scope continue in the enclosing (outer) environment.
+// Bindings are numbered by slot in the order they are declared, the order
+// in which the resolver numbers the declarations of a scope
+type Environment struct {
+	slots []binding
+	index map[string]int // slot of each name
+	outer *Environment
+}
+
+// NewEnvironment returns an empty top level environment
+func NewEnvironment() *Environment {
+	return &Environment{index: make(map[string]int)}
+}
+
+// NewEnclosedEnvironment returns an empty environment nested in outer,
+// used for the body of a block
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// Get returns the value bound to name in this scope or, if missing, in the closest enclosing one
+func (e *Environment) Get(name string) (Object, bool) {
+	i, ok := e.index[name]
+	if !ok {
+		if e.outer != nil {
+			return e.outer.Get(name)
+		}
+		return nil, false
+	}
+	return e.slots[i].value, true
+}
+
+// GetAt returns the value in slot of the environment depth scopes above this one,
+// without searching the other scopes. The slot is only trusted when it holds name,
+// otherwise name is looked up in that environment, as the resolution may have been
+// made for bindings that were not all declared, e.g. after a runtime error
+func (e *Environment) GetAt(depth, slot int, name string) (Object, bool) {
+	env := e
+	for i := 0; i < depth && env != nil; i++ {
+		env = env.outer
+	}
+	if env == nil {
+		return nil, false
+	}
+	if slot >= 0 && slot < len(env.slots) && env.slots[slot].name == name {
+		return env.slots[slot].value, true
+	}
+	i, ok := env.index[name]
+	if !ok {
+		return nil, false
+	}
+	return env.slots[i].value, true
+}
+
+// Declared returns the names declared in this scope, in the order of their slots
+func (e *Environment) Declared() []string {
+	names := make([]string, len(e.slots))
+	for i, b := range e.slots {
+		names[i] = b.name
+	}
+	return names
+}
+
+// Names returns the names bound in this scope and in the enclosing ones, sorted
+func (e *Environment) Names() []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for env := e; env != nil; env = env.outer {
+		for name := range env.index {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Declare binds name to val in this scope. A name declared in an enclosing
+// scope is shadowed, while a name already declared in this scope is an error
+func (e *Environment) Declare(name string, val Object, constant bool) error {
+	if i, ok := e.index[name]; ok {
+		if e.slots[i].constant {
+			return fmt.Errorf("cannot reassign constant %s", name)
+		}
+		return fmt.Errorf("%s is already declared in this scope", name)
+	}
+	e.index[name] = len(e.slots)
+	e.slots = append(e.slots, binding{name: name, value: val, constant: constant})
+	return nil
+}
@@ -0,0 +1,117 @@
+package object
+
+import "testing"
+
+func TestEnvironmentShadowing(t *testing.T) {
+	outer := NewEnvironment()
+	if err := outer.Declare("x", &Integer{Value: 1}, false); err != nil {
+		t.Fatalf("outer.Declare returned error: %s", err)
+	}
+
+	inner := NewEnclosedEnvironment(outer)
+	if err := inner.Declare("x", &Integer{Value: 2}, false); err != nil {
+		t.Fatalf("inner.Declare returned error: %s", err)
+	}
+	if err := inner.Declare("y", &Integer{Value: 3}, false); err != nil {
+		t.Fatalf("inner.Declare returned error: %s", err)
+	}
+
+	tests := []struct {
+		env      *Environment
+		name     string
+		expected int64
+		exists   bool
+	}{
+		{inner, "x", 2, true},
+		{inner, "y", 3, true},
+		{outer, "x", 1, true},
+		{outer, "y", 0, false},
+	}
+
+	for _, tt := range tests {
+		val, ok := tt.env.Get(tt.name)
+		if ok != tt.exists {
+			t.Errorf("Get(%q) exists not %t. got=%t", tt.name, tt.exists, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		integer, isInt := val.(*Integer)
+		if !isInt {
+			t.Errorf("Get(%q) not *Integer. got=%T", tt.name, val)
+			continue
+		}
+		if integer.Value != tt.expected {
+			t.Errorf("Get(%q) not %d. got=%d", tt.name, tt.expected, integer.Value)
+		}
+	}
+}
+
+func TestEnvironmentRedeclaration(t *testing.T) {
+	env := NewEnvironment()
+	env.Declare("x", &Integer{Value: 1}, false)
+	env.Declare("c", &Integer{Value: 1}, true)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"x", "x is already declared in this scope"},
+		{"c", "cannot reassign constant c"},
+	}
+
+	for _, tt := range tests {
+		err := env.Declare(tt.name, &Integer{Value: 2}, false)
+		if err == nil {
+			t.Errorf("Declare(%q) expected error", tt.name)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+
+	inner := NewEnclosedEnvironment(env)
+	if err := inner.Declare("c", &Integer{Value: 2}, false); err != nil {
+		t.Errorf("shadowing a constant in an inner scope returned error: %s", err)
+	}
+}
+
+func TestEnvironmentSlots(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Declare("a", &Integer{Value: 1}, false)
+	outer.Declare("b", &Integer{Value: 2}, true)
+	inner := NewEnclosedEnvironment(outer)
+	inner.Declare("c", &Integer{Value: 3}, false)
+
+	if got := outer.Declared(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Declared() wrong. got=%v", got)
+	}
+
+	tests := []struct {
+		depth, slot int
+		name        string
+		expected    int64
+		exists      bool
+	}{
+		{0, 0, "c", 3, true},
+		{1, 0, "a", 1, true},
+		{1, 1, "b", 2, true},
+		{1, 0, "b", 2, true}, // stale slot, found by name
+		{1, 5, "a", 1, true},
+		{0, 0, "a", 0, false}, // not in that scope
+		{2, 0, "a", 0, false},
+	}
+
+	for _, tt := range tests {
+		val, ok := inner.GetAt(tt.depth, tt.slot, tt.name)
+		if ok != tt.exists {
+			t.Errorf("GetAt(%d, %d, %q) exists not %t. got=%t", tt.depth, tt.slot, tt.name, tt.exists, ok)
+			continue
+		}
+		if ok && val.(*Integer).Value != tt.expected {
+			t.Errorf("GetAt(%d, %d, %q) not %d. got=%d", tt.depth, tt.slot, tt.name, tt.expected, val.(*Integer).Value)
+		}
+	}
+}
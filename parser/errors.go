@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"blank/severity"
+	"blank/token"
+	"fmt"
+	"sort"
+)
+
+// Error codes of the parser
+const (
+	ErrUnexpectedToken = "P001"
+	ErrNoPrefixParse   = "P002"
+	ErrInvalidInteger  = "P003"
+	ErrConstReassign   = "P004"
+	ErrTooManyErrors   = "P005"
+	ErrIllegalToken    = "P006"
+	ErrRead            = "P007"
+)
+
+// MaxErrors is the number of errors after which the parser stops reading its input
+const MaxErrors = 10
+
+// bailout is raised by the parser to abandon the input after too many errors
+type bailout struct{}
+
+// ParseError is a syntax error found by the parser
+type ParseError struct {
+	Pos      token.Pos
+	End      token.Pos // position right after the offending token
+	Code     string
+	Severity severity.Level
+	Expected token.TokenType // the token the parser wanted, empty when any other token would do
+	Actual   token.TokenType // the token found instead
+	Msg      string
+}
+
+// Error renders the error for humans as "position: message"
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// ErrorList is a list of parse errors. It implements error, so a parser
+// result can be returned as a single error value
+type ErrorList []*ParseError
+
+// Add appends a new error to the list
+func (l *ErrorList) Add(e *ParseError) {
+	*l = append(*l, e)
+}
+
+func (l ErrorList) Len() int      { return len(l) }
+func (l ErrorList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+// Less orders the errors by filename, position, code and message
+func (l ErrorList) Less(i, j int) bool {
+	a, b := l[i], l[j]
+	if a.Pos.Filename != b.Pos.Filename {
+		return a.Pos.Filename < b.Pos.Filename
+	}
+	if a.Pos.Offset != b.Pos.Offset {
+		return a.Pos.Offset < b.Pos.Offset
+	}
+	if a.Code != b.Code {
+		return a.Code < b.Code
+	}
+	return a.Msg < b.Msg
+}
+
+// Sort sorts the list in source order
+func (l ErrorList) Sort() {
+	sort.Stable(l)
+}
+
+// RemoveDuplicates sorts the list and removes the errors reported more than once
+// at the same position with the same code
+func (l *ErrorList) RemoveDuplicates() {
+	l.Sort()
+	var last *ParseError
+	i := 0
+	for _, e := range *l {
+		if last == nil || e.Pos != last.Pos || e.Code != last.Code || e.Msg != last.Msg {
+			last = e
+			(*l)[i] = e
+			i++
+		}
+	}
+	*l = (*l)[:i]
+}
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Err returns the list as an error, or nil when it is empty
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
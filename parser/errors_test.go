@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"blank/lexer"
+	"blank/severity"
+	"blank/token"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseErrorFields(t *testing.T) {
+	p := New(lexer.NewFile("main.blank", "var 5 = x;"))
+	p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors")
+	}
+
+	err := errors[0]
+	if err.Code != ErrUnexpectedToken {
+		t.Errorf("err.Code not %s. got=%s", ErrUnexpectedToken, err.Code)
+	}
+	if err.Severity != severity.Error {
+		t.Errorf("err.Severity not %s. got=%s", severity.Error, err.Severity)
+	}
+	if err.Expected != token.IDENT {
+		t.Errorf("err.Expected not %s. got=%s", token.IDENT, err.Expected)
+	}
+	if err.Actual != token.INT {
+		t.Errorf("err.Actual not %s. got=%s", token.INT, err.Actual)
+	}
+	expectedPos := token.Pos{Filename: "main.blank", Line: 1, Column: 5, Offset: 4}
+	if err.Pos != expectedPos {
+		t.Errorf("err.Pos not %+v. got=%+v", expectedPos, err.Pos)
+	}
+}
+
+func TestErrorListSortAndRemoveDuplicates(t *testing.T) {
+	at := func(offset int) token.Pos {
+		return token.Pos{Line: 1, Column: offset + 1, Offset: offset}
+	}
+	var list ErrorList
+	list.Add(&ParseError{Pos: at(7), Code: ErrNoPrefixParse, Msg: "second"})
+	list.Add(&ParseError{Pos: at(2), Code: ErrUnexpectedToken, Msg: "first"})
+	list.Add(&ParseError{Pos: at(7), Code: ErrNoPrefixParse, Msg: "second"})
+
+	list.RemoveDuplicates()
+
+	if len(list) != 2 {
+		t.Fatalf("list does not contain 2 errors. got=%d", len(list))
+	}
+	if list[0].Msg != "first" || list[1].Msg != "second" {
+		t.Errorf("list not sorted. got=%v", list)
+	}
+	expected := "1:3: first (and 1 more errors)"
+	if list.Error() != expected {
+		t.Errorf("list.Error() wrong. expected=%q, got=%q", expected, list.Error())
+	}
+	if (ErrorList{}).Err() != nil {
+		t.Errorf("empty list Err() is not nil")
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedError      string
+		expectedStatements int
+	}{
+		{"var = 5; var y = 10; y;", "1:5: expected next token to be IDENT, got = instead", 2},
+		{"var x 5; x;", "1:7: expected next token to be =, got INT instead", 1},
+		{"5 + * 3; var y = 1;", "1:5: no prefix parse function for * found", 2},
+		{"return ); var a = 1;", "1:8: no prefix parse function for ) found", 2},
+		{"var = 5\nvar y = 10;", "1:5: expected next token to be IDENT, got = instead", 1},
+		{"if (true) { var = 1; var y = 2; } var z = 3;", "1:17: expected next token to be IDENT, got = instead", 2},
+		{"if (true) { var x 1 } var z = 3;", "1:19: expected next token to be =, got INT instead", 2},
+		{"var a = 1; /* never closed\nvar b = 2;", "1:12: unterminated block comment", 2},
+		{"var a = \"never closed;", "1:9: unterminated string", 1},
+		{"var a = 1 @ 2; a;", "1:11: illegal character \"@\"", 3},
+		{"if (x { 1 }\nvar y = 2;", "1:7: expected next token to be ), got { instead", 2},
+		{"if (x + 1 { var a = 1; if (a) { 2 } }\nvar y = 2;", "1:11: expected next token to be ), got { instead", 2},
+		{"if (true) { if (x { 1 } var a = 2; } var b = 3;", "1:19: expected next token to be ), got { instead", 2},
+		{"var a = 1;\nif (true) { var = 1; }\nputs(a);", "2:17: expected next token to be IDENT, got = instead", 3},
+		{"if (true) { if (true) { var = 1; } }\na;\nb;", "1:29: expected next token to be IDENT, got = instead", 3},
+		{"if (true) { 1 + ; 2 };\n-a;", "1:17: no prefix parse function for ; found", 2},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("input %q: expected 1 error, got=%d (%v)", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("input %q: wrong error. expected=%q, got=%q",
+				tt.input, tt.expectedError, errors[0].Error())
+		}
+		if len(program.Statements) != tt.expectedStatements {
+			t.Errorf("input %q: expected %d statements, got=%d (%s)",
+				tt.input, tt.expectedStatements, len(program.Statements), program)
+		}
+	}
+}
+
+func TestErrorRecoveryKeepsLaterErrors(t *testing.T) {
+	input := "if (true) { var = 1; }\nputs(@);\nvar z = 2;"
+	expected := []string{
+		"1:17: expected next token to be IDENT, got = instead",
+		"2:6: illegal character \"@\"",
+	}
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors, got=%d (%v)", len(expected), len(errors), errors)
+	}
+	for i, e := range errors {
+		if e.Error() != expected[i] {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, expected[i], e.Error())
+		}
+	}
+	if len(program.Statements) != 3 {
+		t.Errorf("expected 3 statements, got=%d (%s)", len(program.Statements), program)
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	input := ""
+	for i := 0; i < 2*MaxErrors; i++ {
+		input += "var = 1;\n"
+	}
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	if program == nil {
+		t.Fatalf("ParseProgram() returned nil after too many errors")
+	}
+	errors := p.Errors()
+	if len(errors) != MaxErrors+1 {
+		t.Fatalf("expected %d errors, got=%d", MaxErrors+1, len(errors))
+	}
+	last := errors[len(errors)-1]
+	if last.Code != ErrTooManyErrors {
+		t.Errorf("last error code not %s. got=%s (%s)", ErrTooManyErrors, last.Code, last)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	readErr := errors.New("disk failure")
+	source := io.MultiReader(strings.NewReader("var x = 1;\nvar y = x;\n"), iotest.ErrReader(readErr))
+	p := New(lexer.NewReader("main.blank", source))
+	program := p.ParseProgram()
+
+	if len(program.Statements) != 2 {
+		t.Errorf("expected 2 statements, got=%d (%s)", len(program.Statements), program)
+	}
+	errs := p.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got=%d (%v)", len(errs), errs)
+	}
+	if errs[0].Code != ErrRead {
+		t.Errorf("error code not %s. got=%s", ErrRead, errs[0].Code)
+	}
+	if expected := "main.blank:3:1: error reading source: disk failure"; errs[0].Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errs[0].Error())
+	}
+}
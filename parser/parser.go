@@ -3,7 +3,10 @@ package parser
 import (
 	"blank/ast"
 	"blank/lexer"
+	"blank/severity"
 	"blank/token"
+	"fmt"
+	"io"
 )
 
 type (
@@ -15,16 +18,36 @@ type Parser struct {
 	l         *lexer.Lexer
 	curToken  token.Token
 	peekToken token.Token
-	errors    []string
+	errors    ErrorList
+	recovered int // number of errors synchronize already skipped the tokens of
+
+	// comments holds every comment group read so far, curLead and peekLead
+	// the groups right before curToken and peekToken, if any
+	comments []*ast.CommentGroup
+	curLead  *ast.CommentGroup
+	peekLead *ast.CommentGroup
+
+	// constants holds, for each open block, the names declared with const,
+	// so that a later declaration of the same name in that block can be
+	// reported as a reassignment
+	constants []map[string]bool
+
+	// trace, if set, receives the trace of the parse functions, indented by indent
+	trace  io.Writer
+	indent int
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
 
+// New returns a parser reading the tokens of l. The lexer is switched to
+// lexer.ScanComments mode so that comments can be attached to the program
 func New(l *lexer.Lexer) *Parser {
+	l.SetMode(l.Mode() | lexer.ScanComments)
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:         l,
+		errors:    ErrorList{},
+		constants: []map[string]bool{{}},
 	}
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -33,10 +56,14 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -47,19 +74,64 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	return p
 }
 
 func (p *Parser) nextToken() {
-	p.curToken = p.peekToken
-	p.peekToken = p.l.NextToken()
+	p.curToken, p.curLead = p.peekToken, p.peekLead
+	p.peekToken, p.peekLead = p.readToken()
 }
 
-func (p *Parser) ParseProgram() *ast.Program {
-	program := &ast.Program{}
+// readToken returns the next token that is not a comment, together with the comment
+// group that ends on the line right before it. Comments are grouped by consecutive lines,
+// except that a comment on the line of the previous token starts its own group
+func (p *Parser) readToken() (token.Token, *ast.CommentGroup) {
+	var group *ast.CommentGroup
+	for {
+		tok := p.l.NextToken()
+		if tok.Type != token.COMMENT {
+			if group != nil && group.End().Line+1 >= tok.Pos.Line && group.Pos().Line != p.curToken.End.Line {
+				return tok, group
+			}
+			return tok, nil
+		}
+		if group == nil || tok.Pos.Line > group.End().Line+1 || group.Pos().Line == p.curToken.End.Line {
+			group = &ast.CommentGroup{}
+			p.comments = append(p.comments, group)
+		}
+		group.List = append(group.List, &ast.Comment{Token: tok})
+	}
+}
+
+// ParseProgram parses the whole input. Parsing goes on after a syntax error,
+// until MaxErrors errors were reported. When the source could not be read to
+// the end, the read error is reported where the input stopped
+func (p *Parser) ParseProgram() (program *ast.Program) {
+	defer un(trace(p, "ParseProgram"))
+	program = &ast.Program{}
 	program.Statements = []ast.Statement{}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+		}
+		if err := p.l.Err(); err != nil {
+			p.errors.Add(&ParseError{
+				Pos:      p.peekToken.Pos,
+				End:      p.peekToken.End,
+				Code:     ErrRead,
+				Severity: severity.Error,
+				Msg:      fmt.Sprintf("error reading source: %s", err),
+			})
+		}
+		p.errors.RemoveDuplicates()
+		program.Comments = p.comments
+	}()
+
 	for p.curToken.Type != token.EOF {
-		stmt := p.parseStatement()
+		stmt := p.parseStatementAndSync()
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
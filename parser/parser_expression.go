@@ -3,8 +3,8 @@ package parser
 import (
 	"blank/ast"
 	"blank/token"
-	"fmt"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -28,11 +28,13 @@ var operatorsPrecendence = map[token.TokenType]int{
 	token.GT:       LESSGREATER,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
+	token.LPAREN:   CALL,
 }
 
 // parseExpressionStatement parses a whole expression. Example: 2 - 2 * 5 + 4;
 // Returns a reference to a expression statement node
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+	defer un(trace(p, "parseExpressionStatement"))
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 	stmt.Expression = p.parseExpression(LOWEST)
 	if p.peekTokenIs(token.SEMICOLON) {
@@ -52,17 +54,22 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 //		/	\
 //	prefix	 prefix
 func (p *Parser) parseExpression(precedence int) ast.Expression {
+	defer un(trace(p, "parseExpression"))
+	if p.trace != nil {
+		p.printTrace("precedence %s", precedenceNames[precedence])
+	}
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
-		msg := fmt.Sprintf("no prefix parse function for %s found", p.curToken.Type)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken, ErrNoPrefixParse, "",
+			"no prefix parse function for %s found", p.curToken.Type)
 		return nil
 	}
 	leftExp := prefix()
 
-	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
+	for p.continueExpression(precedence) {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
+			p.printTrace("stop: no infix parse function for %s", p.peekToken.Type)
 			return leftExp
 		}
 		p.nextToken()
@@ -74,6 +81,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 // parsePrefixExpression creates prefix expression node and returns it reference.
 func (p *Parser) parsePrefixExpression() ast.Expression {
+	defer un(trace(p, "parsePrefixExpression"))
 	stmtPrefix := &ast.PrefixExpression{Token: p.curToken}
 
 	p.nextToken()
@@ -85,23 +93,32 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 
 // parseIdentifier creates identifier expression node and returns it reference.
 func (p *Parser) parseIdentifier() ast.Expression {
+	defer un(trace(p, "parseIdentifier"))
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
 
 // parseIntegerLiteral creates integer literal expression node and returns it reference.
 func (p *Parser) parseIntegerLiteral() ast.Expression {
+	defer un(trace(p, "parseIntegerLiteral"))
 	stmtInt := &ast.IntegerLiteral{Token: p.curToken}
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("Error, %s is not a number!!", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken, ErrInvalidInteger, token.INT,
+			"Error, %s is not a number!!", p.curToken.Literal)
 	}
 	stmtInt.Value = value
 	return stmtInt
 }
 
+// parseStringLiteral creates string literal expression node and returns it reference.
+func (p *Parser) parseStringLiteral() ast.Expression {
+	defer un(trace(p, "parseStringLiteral"))
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
 // parseInfixExpression creates infix expression node and returns it reference.
 func (p *Parser) parseInfixExpression(leftExp ast.Expression) ast.Expression {
+	defer un(trace(p, "parseInfixExpression"))
 	stmtInfix := &ast.InfixExpression{
 		Left:     leftExp,
 		Operator: p.curToken,
@@ -111,13 +128,109 @@ func (p *Parser) parseInfixExpression(leftExp ast.Expression) ast.Expression {
 	return stmtInfix
 }
 
+// parseCallExpression creates call expression node, with the arguments between the parentheses, and returns it reference.
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	defer un(trace(p, "parseCallExpression"))
+	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	exp.Arguments = p.parseCallArguments()
+	if exp.Arguments == nil {
+		return nil
+	}
+	exp.Rparen = p.curToken
+	return exp
+}
+
+// parseCallArguments parses a comma separated list of expressions up to the closing parenthesis.
+// Returns nil when the list is not closed
+func (p *Parser) parseCallArguments() []ast.Expression {
+	defer un(trace(p, "parseCallArguments"))
+	args := []ast.Expression{}
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return args
+	}
+	p.nextToken()
+	args = append(args, p.parseExpression(LOWEST))
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		args = append(args, p.parseExpression(LOWEST))
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return args
+}
+
 // parseBoolean creates boolean expression node and returns it reference.
 func (p *Parser) parseBoolean() ast.Expression {
+	defer un(trace(p, "parseBoolean"))
 	return &ast.BooleanExpression{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
 
+// parseGroupedExpression parses an expression between parentheses, which only
+// change the shape of the tree, so no node is created for them.
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	defer un(trace(p, "parseGroupedExpression"))
+	p.nextToken()
+	exp := p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return exp
+}
+
+// parseIfExpression creates if expression node, with its optional else block, and returns it reference.
+func (p *Parser) parseIfExpression() ast.Expression {
+	defer un(trace(p, "parseIfExpression"))
+	expression := &ast.IfExpression{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	expression.Condition = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	expression.Consequence = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.ELSE) {
+		p.nextToken()
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		expression.Alternative = p.parseBlockStatement()
+	}
+	return expression
+}
+
+// parseIllegal reports the input the lexer could not make a token of.
+func (p *Parser) parseIllegal() ast.Expression {
+	defer un(trace(p, "parseIllegal"))
+	switch literal := p.curToken.Literal; {
+	case strings.HasPrefix(literal, "/*"):
+		p.addError(p.curToken, ErrIllegalToken, "", "unterminated block comment")
+	case strings.HasPrefix(literal, `"`):
+		p.addError(p.curToken, ErrIllegalToken, "", "unterminated string")
+	default:
+		p.addError(p.curToken, ErrIllegalToken, "", "illegal character %q", literal)
+	}
+	return nil
+}
+
 // HELPERS
 
+// Precedence returns the precedence of an infix operator, or LOWEST for any other token
+func Precedence(t token.TokenType) int {
+	if p, ok := operatorsPrecendence[t]; ok {
+		return p
+	}
+	return LOWEST
+}
+
 // peekPrecedence returns, if exists, the operator precedence of the next token, otherwise,
 // returns LOWEST
 func (p *Parser) peekPrecedence() int {
@@ -2,15 +2,21 @@ package parser
 
 import (
 	"blank/ast"
+	"blank/severity"
 	"blank/token"
 	"fmt"
 )
 
 // parseStatement returns the current token parsed
 func (p *Parser) parseStatement() ast.Statement {
+	defer un(trace(p, "parseStatement"))
 	switch p.curToken.Type {
-	case token.VAR:
-		return p.parseVarStatement()
+	case token.VAR, token.CONST:
+		// a failed declaration must come back as a nil interface, not a nil *ast.VarStatement
+		if stmt := p.parseVarStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
 	case token.RETURN:
 		return p.parseReturnStatement()
 	default:
@@ -18,19 +24,68 @@ func (p *Parser) parseStatement() ast.Statement {
 	}
 }
 
-// parseVarStatement returns a var statement node based on its token
+// parseStatementAndSync parses a statement and, if it reported errors, skips
+// the tokens left from it, so that a single mistake yields a single error.
+// Errors a statement of an inner block already recovered from are not skipped
+// again, as the enclosing statement went on parsing after them
+func (p *Parser) parseStatementAndSync() ast.Statement {
+	errors := len(p.errors)
+	stmt := p.parseStatement()
+	if len(p.errors) > errors && len(p.errors) > p.recovered {
+		p.synchronize()
+		p.recovered = len(p.errors)
+	}
+	return stmt
+}
+
+// synchronize advances until the end of the current statement: a semicolon,
+// or right before a closing brace or a token that starts a new statement.
+// The blocks opened by the broken statement are skipped up to their closing brace,
+// so that the brace is not taken for the end of an enclosing block
+func (p *Parser) synchronize() {
+	depth := 0
+	for !p.curTokenIs(token.EOF) && (depth > 0 || !p.curTokenIs(token.SEMICOLON)) {
+		switch p.peekToken.Type {
+		case token.EOF:
+			return
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case token.VAR, token.CONST, token.RETURN, token.IF, token.FUNCTION:
+			if depth == 0 {
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
+// parseVarStatement returns a var statement node based on its token.
+// Both var and const declarations are parsed here, const ones are flagged as Constant
 func (p *Parser) parseVarStatement() *ast.VarStatement {
-	stmt := &ast.VarStatement{Token: p.curToken}
+	defer un(trace(p, "parseVarStatement"))
+	stmt := &ast.VarStatement{Token: p.curToken, Constant: p.curTokenIs(token.CONST), Doc: docComment(p.curLead)}
 	if !p.expectPeek(token.IDENT) {
 		return nil
 	}
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	scope := p.constants[len(p.constants)-1]
+	if scope[stmt.Name.Value] {
+		p.constReassignError(stmt.Name)
+	}
+	if stmt.Constant {
+		scope[stmt.Name.Value] = true
+	}
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
-	// TODO: We're skipping the expressions until we
-	// encounter a semicolon
-	for !p.curTokenIs(token.SEMICOLON) {
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stmt
@@ -38,18 +93,57 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 
 // parseReturnStatement returns a return statement node based on its token
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
+	defer un(trace(p, "parseReturnStatement"))
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 	p.nextToken()
-	// TODO: We're skipping the expressions until we
-	// encounter a semicolon
-	for !p.curTokenIs(token.SEMICOLON) {
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stmt
 }
 
+// parseBlockStatement returns a block statement node with every statement until the closing brace.
+// Each block opens a new scope, so constants declared inside it may shadow outer ones
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	defer un(trace(p, "parseBlockStatement"))
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+
+	p.constants = append(p.constants, map[string]bool{})
+	defer func() { p.constants = p.constants[:len(p.constants)-1] }()
+
+	p.nextToken()
+	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		stmt := p.parseStatementAndSync()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+	if p.curTokenIs(token.RBRACE) {
+		block.Rbrace = p.curToken
+	}
+	return block
+}
+
 // HELPERS
 
+// docComment returns the trailing doc comments (/// or /** */) of group, or nil if there are none
+func docComment(group *ast.CommentGroup) *ast.CommentGroup {
+	if group == nil {
+		return nil
+	}
+	start := len(group.List)
+	for start > 0 && group.List[start-1].IsDoc() {
+		start--
+	}
+	if start == len(group.List) {
+		return nil
+	}
+	return &ast.CommentGroup{List: group.List[start:]}
+}
+
 // expectPeek validates the next token
 func (p *Parser) expectPeek(t token.TokenType) bool {
 	if p.peekTokenIs(t) {
@@ -71,14 +165,44 @@ func (p *Parser) peekTokenIs(t token.TokenType) bool {
 	return p.peekToken.Type == t
 }
 
-// Errors returns the sintax error array
-func (p *Parser) Errors() []string {
+// Errors returns the sintax errors, sorted in source order
+func (p *Parser) Errors() ErrorList {
 	return p.errors
 }
 
-// peekError appends a token to sintax error array
+// addError appends an error about tok to the sintax error list.
+// Once MaxErrors errors were reported the parser gives up on the input
+func (p *Parser) addError(tok token.Token, code string, expected token.TokenType, format string, a ...interface{}) {
+	p.errors.Add(&ParseError{
+		Pos:      tok.Pos,
+		End:      tok.End,
+		Code:     code,
+		Severity: severity.Error,
+		Expected: expected,
+		Actual:   tok.Type,
+		Msg:      fmt.Sprintf(format, a...),
+	})
+	p.printTrace("error: %s", p.errors[len(p.errors)-1].Msg)
+	if len(p.errors) == MaxErrors {
+		p.errors.Add(&ParseError{
+			Pos:      tok.Pos,
+			End:      tok.End,
+			Code:     ErrTooManyErrors,
+			Severity: severity.Error,
+			Msg:      "too many errors",
+		})
+		panic(bailout{})
+	}
+}
+
+// peekError appends an unexpected next token to sintax error list
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
-		t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addError(p.peekToken, ErrUnexpectedToken, t,
+		"expected next token to be %s, got %s instead", t, p.peekToken.Type)
+}
+
+// constReassignError appends a reassignment of a constant to sintax error list
+func (p *Parser) constReassignError(name *ast.Identifier) {
+	p.addError(name.Token, ErrConstReassign, "",
+		"cannot reassign constant %s", name.Value)
 }
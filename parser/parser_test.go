@@ -5,6 +5,7 @@ import (
 	"blank/lexer"
 	"blank/token"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -77,8 +78,8 @@ func checkParserErrors(t *testing.T, p *Parser) {
 
 	t.Errorf("parser has %d errors", len(errors))
 
-	for _, msg := range errors {
-		t.Errorf("parser error: %q", msg)
+	for _, err := range errors {
+		t.Errorf("parser error: %q", err.Error())
 	}
 	t.FailNow()
 }
@@ -420,3 +421,458 @@ func testBooleanLiteral(t *testing.T, exp ast.Expression, value bool) bool {
 	}
 	return true
 }
+
+func TestConstStatements(t *testing.T) {
+	input := `
+		const x = 5;
+		var y = true;
+		const foobar = y;
+	`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	tests := []struct {
+		expectedLiteral    string
+		expectedIdentifier string
+		expectedConstant   bool
+		expectedValue      interface{}
+	}{
+		{"const", "x", true, 5},
+		{"var", "y", false, true},
+		{"const", "foobar", true, "y"},
+	}
+
+	if len(program.Statements) != len(tests) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(tests), len(program.Statements))
+	}
+
+	for i, tt := range tests {
+		stmt, ok := program.Statements[i].(*ast.VarStatement)
+		if !ok {
+			t.Fatalf("program.Statements[%d] not *ast.VarStatement. got=%T", i, program.Statements[i])
+		}
+		if stmt.TokenLiteral() != tt.expectedLiteral {
+			t.Errorf("stmt.TokenLiteral not %q. got=%q", tt.expectedLiteral, stmt.TokenLiteral())
+		}
+		if stmt.Name.Value != tt.expectedIdentifier {
+			t.Errorf("stmt.Name.Value not %s. got=%s", tt.expectedIdentifier, stmt.Name.Value)
+		}
+		if stmt.Constant != tt.expectedConstant {
+			t.Errorf("stmt.Constant not %t. got=%t", tt.expectedConstant, stmt.Constant)
+		}
+		if !testGenericLiteralExpression(t, stmt.Value, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestConstReassignment(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors int
+	}{
+		{"const x = 5; var x = 10;", 1},
+		{"const x = 5; const x = 10;", 1},
+		{"var x = 5; var x = 10;", 0},
+		{"var x = 5; const x = 10; var x = 1;", 1},
+		{"const x = 5; if (true) { const x = 10; var y = x; }", 0},
+		{"if (true) { const x = 5; var x = 10; }", 1},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		if len(p.Errors()) != tt.expectedErrors {
+			t.Errorf("input %q: expected %d errors, got=%d (%v)",
+				tt.input, tt.expectedErrors, len(p.Errors()), p.Errors())
+		}
+	}
+}
+
+func TestIfExpression(t *testing.T) {
+	input := `if (x < y) { x } else { var z = y; z }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+			len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	condition, ok := exp.Condition.(*ast.InfixExpression)
+	if !ok {
+		t.Fatalf("exp.Condition is not ast.InfixExpression. got=%T", exp.Condition)
+	}
+	if !testGenericLiteralExpression(t, condition.Left, "x") ||
+		!testGenericLiteralExpression(t, condition.Right, "y") {
+		return
+	}
+
+	if len(exp.Consequence.Statements) != 1 {
+		t.Errorf("consequence is not 1 statement. got=%d", len(exp.Consequence.Statements))
+	}
+	consequence, ok := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T",
+			exp.Consequence.Statements[0])
+	}
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if exp.Alternative == nil {
+		t.Fatalf("exp.Alternative is nil")
+	}
+	if len(exp.Alternative.Statements) != 2 {
+		t.Fatalf("alternative is not 2 statements. got=%d", len(exp.Alternative.Statements))
+	}
+	if !testVarStatement(t, exp.Alternative.Statements[0], "z") {
+		return
+	}
+}
+
+func TestCallExpression(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+			len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T", stmt.Expression)
+	}
+	if !testIdentifier(t, exp.Function, "add") {
+		return
+	}
+	if len(exp.Arguments) != 3 {
+		t.Fatalf("wrong number of arguments. got=%d", len(exp.Arguments))
+	}
+	testIntegerLiteral(t, exp.Arguments[0], 1)
+	for i, expected := range map[int]string{1: "2 * 3", 2: "4 + 5"} {
+		if _, ok := exp.Arguments[i].(*ast.InfixExpression); !ok || exp.Arguments[i].String() != expected {
+			t.Errorf("exp.Arguments[%d] wrong. expected=%q, got=%T %q", i, expected, exp.Arguments[i], exp.Arguments[i])
+		}
+	}
+	if exp.Pos().Column != 1 || exp.End().Column != 21 {
+		t.Errorf("call span wrong. got=%s - %s", exp.Pos(), exp.End())
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := "var x = -1 + 2;\nif (x) { return x; } else { x }"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	varStmt := program.Statements[0].(*ast.VarStatement)
+	ifExp := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	tests := []struct {
+		node        ast.Node
+		expectedPos string
+		expectedEnd string
+	}{
+		{program, "1:1", "2:32"},
+		{varStmt, "1:1", "1:15"},
+		{varStmt.Name, "1:5", "1:6"},
+		{varStmt.Value, "1:9", "1:15"},
+		{varStmt.Value.(*ast.InfixExpression).Left, "1:9", "1:11"},
+		{ifExp, "2:1", "2:32"},
+		{ifExp.Condition, "2:5", "2:6"},
+		{ifExp.Consequence, "2:8", "2:21"},
+		{ifExp.Consequence.Statements[0], "2:10", "2:18"},
+		{ifExp.Alternative, "2:27", "2:32"},
+	}
+
+	for i, tt := range tests {
+		if tt.node.Pos().String() != tt.expectedPos {
+			t.Errorf("tests[%d] - %T Pos wrong. expected=%s, got=%s",
+				i, tt.node, tt.expectedPos, tt.node.Pos())
+		}
+		if tt.node.End().String() != tt.expectedEnd {
+			t.Errorf("tests[%d] - %T End wrong. expected=%s, got=%s",
+				i, tt.node, tt.expectedEnd, tt.node.End())
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var = 5;", "main.blank:1:5: expected next token to be IDENT, got = instead"},
+		{"var x = 5;\n\n  )", "main.blank:3:3: no prefix parse function for ) found"},
+		{"const x = 1;\nconst x = 2;", "main.blank:2:7: cannot reassign constant x"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.NewFile("main.blank", tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("input %q: expected errors", tt.input)
+			continue
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("input %q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello wörld\t!";`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != "hello wörld\t!" {
+		t.Errorf("literal.Value not %q. got=%q", "hello wörld\t!", literal.Value)
+	}
+	if literal.String() != `"hello wörld\t!"` {
+		t.Errorf("literal.String() not %q. got=%q", `"hello wörld\t!"`, literal.String())
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	input := `/// The answer.
+/// Computed at length.
+const answer = 42;
+
+// not a doc comment
+var plain = 1;
+
+/// separated by a blank line
+
+var detached = 2;
+
+var a = 1; // trailing comment
+/**
+ * Block doc
+ * over two lines.
+ */
+var b = a;
+
+// a note
+/// only this line is doc
+var mixed = b;
+`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	tests := []struct {
+		name        string
+		expectedDoc string
+	}{
+		{"answer", "The answer.\nComputed at length."},
+		{"plain", ""},
+		{"detached", ""},
+		{"a", ""},
+		{"b", "Block doc\nover two lines."},
+		{"mixed", "only this line is doc"},
+	}
+
+	if len(program.Statements) != len(tests) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(tests), len(program.Statements))
+	}
+
+	for i, tt := range tests {
+		stmt := program.Statements[i].(*ast.VarStatement)
+		if stmt.Name.Value != tt.name {
+			t.Fatalf("stmt.Name.Value not %s. got=%s", tt.name, stmt.Name.Value)
+		}
+		if tt.expectedDoc == "" {
+			if stmt.Doc != nil {
+				t.Errorf("%s: stmt.Doc not nil. got=%q", tt.name, stmt.Doc.Text())
+			}
+			continue
+		}
+		if stmt.Doc.Text() != tt.expectedDoc {
+			t.Errorf("%s: stmt.Doc.Text() wrong. expected=%q, got=%q", tt.name, tt.expectedDoc, stmt.Doc.Text())
+		}
+	}
+
+	if len(program.Comments) != 6 {
+		t.Errorf("program.Comments does not contain 6 groups. got=%d", len(program.Comments))
+	}
+}
+
+func TestOperatorPrecedenceParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-a * b", "-a * b"},
+		{"(1 + 2) * 3", "(1 + 2) * 3"},
+		{"1 + (2 + 3) + 4", "1 + (2 + 3) + 4"},
+		{"-(5 + 5)", "-(5 + 5)"},
+		{"!(true == true)", "!(true == true)"},
+		{"a + b * c - d / e", "a + b * c - d / e"},
+		{"1 < 2 == (3 > 4)", "1 < 2 == 3 > 4"},
+		{"(1 < 2) < 3", "1 < 2 < 3"},
+		{"1 - (2 - 3)", "1 - (2 - 3)"},
+		{"-f(x)", "-f(x)"},
+		{"a + add(b * c) + d", "a + add(b * c) + d"},
+		{"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))", "add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := grouped(program.Statements[0].(*ast.ExpressionStatement).Expression); got != tt.expected {
+			t.Errorf("input %q: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestOperatorPrecedenceSexp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a + b * c + d / e - f", "(- (+ (+ a (* b c)) (/ d e)) f)"},
+		{"5 > 4 == 3 < 4", "(== (> 5 4) (< 3 4))"},
+		{"-f(x) * !y", "(* (- (call f x)) (! y))"},
+		{"a * f(b + c)(d)", "(* a (call (call f (+ b c)) d))"},
+		{"-(-1)", "(- (- 1))"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := ast.Sexp(program.Statements[0]); got != tt.expected {
+			t.Errorf("input %q: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+// grouped renders exp with parentheses around the operands that bind weaker than their operator
+func grouped(exp ast.Expression) string {
+	switch exp := exp.(type) {
+	case *ast.PrefixExpression:
+		if _, ok := exp.Right.(*ast.InfixExpression); ok {
+			return exp.TokenLiteral() + "(" + grouped(exp.Right) + ")"
+		}
+		return exp.TokenLiteral() + grouped(exp.Right)
+	case *ast.InfixExpression:
+		precedence := Precedence(exp.Operator.Type)
+		left, right := grouped(exp.Left), grouped(exp.Right)
+		if l, ok := exp.Left.(*ast.InfixExpression); ok && Precedence(l.Operator.Type) < precedence {
+			left = "(" + left + ")"
+		}
+		if r, ok := exp.Right.(*ast.InfixExpression); ok && Precedence(r.Operator.Type) <= precedence {
+			right = "(" + right + ")"
+		}
+		return left + " " + exp.TokenLiteral() + " " + right
+	}
+	return exp.String()
+}
+
+func TestTrace(t *testing.T) {
+	var trace strings.Builder
+	p := New(lexer.New("-a * b"))
+	p.SetTrace(&trace)
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := `ParseProgram ( cur=- "-" peek=IDENT "a"
+. parseStatement ( cur=- "-" peek=IDENT "a"
+. . parseExpressionStatement ( cur=- "-" peek=IDENT "a"
+. . . parseExpression ( cur=- "-" peek=IDENT "a"
+. . . . precedence LOWEST
+. . . . parsePrefixExpression ( cur=- "-" peek=IDENT "a"
+. . . . . parseExpression ( cur=IDENT "a" peek=* "*"
+. . . . . . precedence PREFIX
+. . . . . . parseIdentifier ( cur=IDENT "a" peek=* "*"
+. . . . . . )
+. . . . . . stop: PREFIX >= PRODUCT of *
+. . . . . )
+. . . . )
+. . . . continue: LOWEST < PRODUCT of *
+. . . . parseInfixExpression ( cur=* "*" peek=IDENT "b"
+. . . . . parseExpression ( cur=IDENT "b" peek=EOF ""
+. . . . . . precedence PRODUCT
+. . . . . . parseIdentifier ( cur=IDENT "b" peek=EOF ""
+. . . . . . )
+. . . . . . stop: PRODUCT >= LOWEST of EOF
+. . . . . )
+. . . . )
+. . . . stop: LOWEST >= LOWEST of EOF
+. . . )
+. . )
+. )
+)
+`
+	if trace.String() != expected {
+		t.Errorf("trace wrong. expected=\n%s\ngot=\n%s", expected, trace.String())
+	}
+}
+
+func TestTraceKeepsTree(t *testing.T) {
+	input := `
+var x = if (a < b) { f(a, -b) } else { "c" };
+return x * (1 + 2) == 3;
+var = 4;
+`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+
+	var trace strings.Builder
+	traced := New(lexer.New(input))
+	traced.SetTrace(&trace)
+	tracedProgram := traced.ParseProgram()
+
+	if !ast.Equal(program, tracedProgram, 0) {
+		t.Errorf("tracing changed the tree. expected=%s, got=%s", ast.Sexp(program), ast.Sexp(tracedProgram))
+	}
+	if len(p.Errors()) != len(traced.Errors()) {
+		t.Errorf("tracing changed the errors. expected=%v, got=%v", p.Errors(), traced.Errors())
+	}
+
+	lines := strings.Split(strings.TrimSuffix(trace.String(), "\n"), "\n")
+	if last := lines[len(lines)-1]; last != ")" {
+		t.Errorf("trace does not end at depth 0. got=%q", last)
+	}
+	if !strings.Contains(trace.String(), ". error: expected next token to be IDENT, got = instead\n") {
+		t.Errorf("trace does not report the syntax error. got=\n%s", trace.String())
+	}
+}
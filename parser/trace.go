@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"blank/token"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// precedenceNames are the names of the precedences, as printed in traces
+var precedenceNames = map[int]string{
+	LOWEST:      "LOWEST",
+	EQUALS:      "EQUALS",
+	LESSGREATER: "LESSGREATER",
+	SUM:         "SUM",
+	PRODUCT:     "PRODUCT",
+	PREFIX:      "PREFIX",
+	CALL:        "CALL",
+}
+
+// SetTrace makes the parser write a trace of its work to w: each parse function it
+// enters and leaves, indented by depth, with the current and next tokens, and why
+// each expression goes on with the next operator or stops. A nil w turns tracing off
+func (p *Parser) SetTrace(w io.Writer) {
+	p.trace = w
+	p.indent = 0
+}
+
+// printTrace writes a line to the trace, at the current depth
+func (p *Parser) printTrace(format string, a ...interface{}) {
+	if p.trace == nil {
+		return
+	}
+	fmt.Fprintf(p.trace, "%s%s\n", strings.Repeat(". ", p.indent), fmt.Sprintf(format, a...))
+}
+
+// trace records entering the parse function name, to be used as: defer un(trace(p, "name"))
+func trace(p *Parser, name string) *Parser {
+	if p.trace != nil {
+		p.printTrace("%s ( cur=%s %q peek=%s %q", name,
+			p.curToken.Type, p.curToken.Literal, p.peekToken.Type, p.peekToken.Literal)
+		p.indent++
+	}
+	return p
+}
+
+// un records leaving the parse function entered by the matching trace
+func un(p *Parser) {
+	if p.trace != nil {
+		p.indent--
+		p.printTrace(")")
+	}
+}
+
+// continueExpression reports whether the expression being parsed at precedence goes on
+// with the operator in peekToken, which binds tighter, and traces the decision
+func (p *Parser) continueExpression(precedence int) bool {
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.printTrace("stop: end of statement")
+		return false
+	}
+	peek := p.peekPrecedence()
+	if p.trace != nil {
+		if precedence < peek {
+			p.printTrace("continue: %s < %s of %s", precedenceNames[precedence], precedenceNames[peek], p.peekToken.Type)
+		} else {
+			p.printTrace("stop: %s >= %s of %s", precedenceNames[precedence], precedenceNames[peek], p.peekToken.Type)
+		}
+	}
+	return precedence < peek
+}
@@ -0,0 +1,91 @@
+package repl
+
+import (
+	"blank/cst"
+	"blank/evaluator"
+	"blank/object"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const help = `:tokens       toggle printing the tokens of each input
+:ast          toggle printing the syntax tree of each input
+:time         toggle printing how long each evaluation took
+:env          list the bindings of the session
+:load file    evaluate the program in file
+:save file    write the inputs evaluated so far to file
+:reset        forget every binding and input
+:help         show this help
+`
+
+// command runs the meta-command in line, such as ":load file"
+func (s *session) command(line string) {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+	wantArgs := 0
+	if name == ":load" || name == ":save" {
+		wantArgs = 1
+	}
+	if len(args) != wantArgs {
+		fmt.Fprintf(s.out, "%s takes %d argument(s), see :help\n", name, wantArgs)
+		return
+	}
+
+	switch name {
+	case ":tokens":
+		s.tokens = !s.tokens
+		s.printToggle("tokens", s.tokens)
+	case ":ast":
+		s.ast = !s.ast
+		s.printToggle("ast", s.ast)
+	case ":time":
+		s.time = !s.time
+		s.printToggle("time", s.time)
+	case ":env":
+		for _, name := range s.env.Names() {
+			value, _ := s.env.Get(name)
+			if _, ok := value.(*object.Builtin); !ok {
+				fmt.Fprintf(s.out, "%s = %s\n", name, value.Inspect())
+			}
+		}
+	case ":load":
+		content, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintln(s.out, err)
+			return
+		}
+		s.eval(args[0], string(content))
+	case ":save":
+		source := strings.Join(s.inputs, "")
+		if err := os.WriteFile(args[0], []byte(source), 0644); err != nil {
+			fmt.Fprintln(s.out, err)
+		}
+	case ":reset":
+		s.env = evaluator.NewEnvironment(s.out, nil)
+		s.inputs = nil
+	case ":help":
+		io.WriteString(s.out, help)
+	default:
+		fmt.Fprintf(s.out, "unknown command %s, see :help\n", name)
+	}
+}
+
+func (s *session) printToggle(option string, on bool) {
+	state := "off"
+	if on {
+		state = "on"
+	}
+	fmt.Fprintf(s.out, "%s %s\n", option, state)
+}
+
+// printTree prints the nodes of tree, one per line with their kind and source, indented by depth
+func printTree(out io.Writer, tree *cst.Node, depth int) {
+	fmt.Fprintf(out, "%s%s %s\n", strings.Repeat("  ", depth), tree.Kind, tree.AST.String())
+	for _, child := range tree.Children {
+		if node, ok := child.(*cst.Node); ok {
+			printTree(out, node, depth+1)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package repl
+
+import (
+	"blank/lexer"
+	"blank/token"
+	"strings"
+)
+
+// incomplete reports whether source needs more lines to be a whole program:
+// when it has unclosed braces or parentheses, ends with an operator, a comma
+// or else, or ends inside a string or a block comment
+func incomplete(source string) bool {
+	l := lexer.New(source)
+	depth := 0
+	var last token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LBRACE, token.LPAREN:
+			depth++
+		case token.RBRACE, token.RPAREN:
+			depth--
+		case token.ILLEGAL:
+			if strings.HasPrefix(tok.Literal, `"`) || strings.HasPrefix(tok.Literal, "/*") {
+				return true
+			}
+		}
+		last = tok
+	}
+	if depth > 0 {
+		return true
+	}
+
+	switch last.Type {
+	case token.ASSIGN, token.PLUS, token.MINUS, token.ASTERISK, token.SLASH, token.BANG,
+		token.LT, token.GT, token.LTE, token.GTE, token.EQ, token.NOT_EQ, token.COMMA, token.ELSE:
+		return true
+	}
+	return false
+}
@@ -1,27 +1,180 @@
 package repl
 
 import (
+	"blank/cst"
+	"blank/diagnostics"
+	"blank/evaluator"
+	"blank/format"
 	"blank/lexer"
+	"blank/lineedit"
+	"blank/object"
+	"blank/parser"
 	"blank/token"
 	"bufio"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 )
 
 const PROMPT = "Blank >> "
 
-func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+// CONTINUATION_PROMPT asks for the next line of an incomplete input
+const CONTINUATION_PROMPT = "...... "
+
+// HISTORY_FILE is the file, in the home directory, keeping the lines entered in a terminal
+const HISTORY_FILE = ".blank_history"
+
+// Config changes how Start talks to the user
+type Config struct {
+	Greeting string // written to out before anything else, unless empty
+	Prompt   bool   // write the prompts even when in is not a terminal
+}
+
+// Start reads programs from in and evaluates them in an environment kept across
+// inputs, writing their results and errors to out. An input spans several lines
+// while it is incomplete, such as an unclosed block; an empty line ends it anyway.
+// Lines starting with a colon are meta-commands, listed by :help.
+//
+// When in is a terminal, lines are read with editing, a history kept in
+// HISTORY_FILE and tab completion of keywords and names. Otherwise, as in
+// a pipeline, no prompt is written unless config asks for it
+func Start(in io.Reader, out io.Writer, config Config) {
+	if config.Greeting != "" {
+		io.WriteString(out, config.Greeting+"\n")
+	}
+	s := newSession(out)
+	readLine := s.lineReader(in, config.Prompt)
+	var input strings.Builder
 	for {
-		fmt.Printf(PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		prompt := PROMPT
+		if input.Len() > 0 {
+			prompt = CONTINUATION_PROMPT
+		}
+		line, err := readLine(prompt)
+		if err == lineedit.ErrInterrupted {
+			input.Reset()
+			continue
+		}
+		if err != nil {
 			return
 		}
-		line := scanner.Text()
-		l := lexer.New(line)
+		if input.Len() == 0 && strings.HasPrefix(line, ":") {
+			s.command(line)
+			continue
+		}
+		if input.Len() > 0 {
+			input.WriteString("\n")
+		}
+		input.WriteString(line)
+		source := input.String()
+		if line != "" && incomplete(source) {
+			continue
+		}
+		input.Reset()
+		s.eval("", source)
+	}
+}
+
+// lineReader returns a function reading a line from in after writing a prompt,
+// with a line editor when in is a terminal. Other readers only get the prompt
+// when showPrompt is set
+func (s *session) lineReader(in io.Reader, showPrompt bool) func(prompt string) (string, error) {
+	if !lineedit.IsTerminal(in) {
+		scanner := bufio.NewScanner(in)
+		return func(prompt string) (string, error) {
+			if showPrompt {
+				io.WriteString(s.out, prompt)
+			}
+			if !scanner.Scan() {
+				if showPrompt {
+					io.WriteString(s.out, "\n")
+				}
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.EOF
+			}
+			return scanner.Text(), nil
+		}
+	}
+
+	editor := lineedit.New(in, s.out)
+	editor.Complete = s.complete
+	if home, err := os.UserHomeDir(); err == nil {
+		editor.LoadHistory(filepath.Join(home, HISTORY_FILE))
+	}
+	return func(prompt string) (string, error) {
+		line, err := editor.ReadLine(prompt)
+		if err == nil {
+			editor.AddHistory(line)
+		}
+		return line, err
+	}
+}
+
+// session is the state of a REPL kept across inputs
+type session struct {
+	out    io.Writer
+	env    *object.Environment
+	inputs []string // the inputs evaluated without errors, formatted, written by :save
+
+	tokens bool // print the tokens of each input
+	ast    bool // print the syntax tree of each input
+	time   bool // print how long each evaluation took
+}
+
+func newSession(out io.Writer) *session {
+	return &session{out: out, env: evaluator.NewEnvironment(out, nil)}
+}
+
+// eval parses and evaluates source, read from filename, printing what the options of the session ask for
+func (s *session) eval(filename, source string) {
+	if s.tokens {
+		l := lexer.NewFile(filename, source)
 		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Printf("%+v\n", tok)
+			fmt.Fprintf(s.out, "%s\t%s\t%q\n", tok.Pos, tok.Type, tok.Literal)
+		}
+	}
+
+	p := parser.New(lexer.NewFile(filename, source))
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		diagnostics.Fprint(s.out, source, diagnostics.FromParseErrors(errors))
+		return
+	}
+	if s.ast {
+		tree, _ := cst.Parse(filename, source)
+		printTree(s.out, tree, 0)
+	}
+
+	start := time.Now()
+	evaluated := evaluator.Eval(program, s.env)
+	elapsed := time.Since(start)
+	if err, ok := evaluated.(*object.Error); ok {
+		diagnostics.Fprint(s.out, source, []diagnostics.Diagnostic{diagnostics.FromRuntimeError(err)})
+	} else {
+		s.inputs = append(s.inputs, format.Program(program))
+		if evaluated != nil {
+			io.WriteString(s.out, evaluated.Inspect()+"\n")
+		}
+	}
+	if s.time {
+		fmt.Fprintf(s.out, "evaluated in %s\n", elapsed)
+	}
+}
+
+// complete returns the keywords, built-ins and names bound in the session that start with prefix
+func (s *session) complete(prefix string) []string {
+	var words []string
+	for _, word := range append(token.Keywords(), s.env.Names()...) {
+		if strings.HasPrefix(word, prefix) {
+			words = append(words, word)
 		}
 	}
+	sort.Strings(words)
+	return words
 }
@@ -0,0 +1,205 @@
+package repl
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartKeepsEnvironment(t *testing.T) {
+	input := `var x = 2;
+x * 3
+const name = "blank";
+if (x > 1) { name } else { x }
+var y = x +;
+y
+puts(x)
+`
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out, Config{})
+
+	got := out.String()
+	for _, expected := range []string{"6\n", "blank\n", "error[P002]", "identifier not found: y", "2\nnull\n"} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("output does not contain %q. got=%q", expected, got)
+		}
+	}
+}
+
+func TestStartMultiLineInput(t *testing.T) {
+	input := `var x = 1 +
+  2;
+if (x > 2) {
+  var s = "two
+lines";
+  s
+} else {
+  x
+}
+/* a comment
+over lines */ x * (
+2)
+(1
+
+x
+`
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out, Config{})
+
+	got := out.String()
+	for _, expected := range []string{"two\nlines\n", "6\n", "error[P001]", "3\n"} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("output does not contain %q. got=%q", expected, got)
+		}
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"var x = 1;", false},
+		{"if (x) {", true},
+		{"if (x) { 1 }", false},
+		{"if (x) { 1 } else", true},
+		{"f(1,", true},
+		{"f(1, 2)", false},
+		{"var x =", true},
+		{"1 +", true},
+		{"1 + // comment", true},
+		{`"open`, true},
+		{"/* open", true},
+		{"}", false},
+		{"@", false},
+	}
+
+	for _, tt := range tests {
+		if got := incomplete(tt.input); got != tt.expected {
+			t.Errorf("incomplete(%q) wrong. expected=%t, got=%t", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestStartCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.blank")
+	input := `var x = 1 + 2;
+:tokens
+x
+:tokens
+:ast
+-x
+:ast
+:env
+:save ` + path + `
+:reset
+:env
+:load ` + path + `
+:env
+:time
+x
+:load
+:nope
+`
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out, Config{})
+
+	expected := `tokens on
+1:1	IDENT	"x"
+3
+tokens off
+ast on
+Program -x
+  ExpressionStatement -x
+    PrefixExpression -x
+      Identifier x
+-3
+ast off
+x = 3
+-3
+x = 3
+time on
+3
+evaluated in `
+	if got := out.String(); !strings.HasPrefix(got, expected) {
+		t.Fatalf("wrong output.\nexpected prefix=%q\ngot=            %q", expected, got)
+	}
+	if !strings.HasSuffix(out.String(), ":load takes 1 argument(s), see :help\nunknown command :nope, see :help\n") {
+		t.Errorf("wrong errors for bad commands. got=%q", out.String())
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != "var x = 1 + 2;\nx;\n-x;\n" {
+		t.Errorf("wrong saved session. got=%q", saved)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s := newSession(io.Discard)
+	s.eval("", "var result = 1; const re = 2;")
+
+	tests := []struct {
+		prefix   string
+		expected string
+	}{
+		{"re", "re,result,return"},
+		{"pu", "puts"},
+		{"a", "arg,argc"},
+		{"zz", ""},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(s.complete(tt.prefix), ","); got != tt.expected {
+			t.Errorf("prefix %q: wrong completions. expected=%q, got=%q", tt.prefix, tt.expected, got)
+		}
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden transcripts in testdata")
+
+// TestTranscripts runs the REPL, prompts included, on each testdata/*.blank input
+// and compares its output with the transcript in the matching .golden file
+func TestTranscripts(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.blank"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		content, err := os.ReadFile(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		Start(bytes.NewReader(content), &out, Config{Greeting: "Welcome!", Prompt: true})
+
+		golden := strings.TrimSuffix(input, ".blank") + ".golden"
+		if *update {
+			if err := os.WriteFile(golden, out.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		expected, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != string(expected) {
+			t.Errorf("%s: wrong transcript.\nexpected:\n%s\ngot:\n%s", input, expected, out.String())
+		}
+	}
+}
+
+func TestStartWithoutPrompt(t *testing.T) {
+	var out bytes.Buffer
+	Start(strings.NewReader("var x = 1;\nif (x) {\nx + 1\n}\n"), &out, Config{})
+	if out.String() != "2\n" {
+		t.Errorf("wrong output. expected=%q, got=%q", "2\n", out.String())
+	}
+}
@@ -0,0 +1,231 @@
+package resolver
+
+import (
+	"blank/ast"
+	"blank/severity"
+	"blank/token"
+	"fmt"
+	"sort"
+)
+
+// Diagnostic codes reported by the resolver
+const (
+	UndefinedName         = "R001"
+	UsedBeforeDeclaration = "R002"
+	DuplicateDeclaration  = "R003"
+	UnusedVariable        = "R004"
+)
+
+// Diagnostic is a problem found while resolving names
+type Diagnostic struct {
+	Severity severity.Level
+	Code     string
+	Message  string
+	Token    token.Token // the identifier the diagnostic is about
+}
+
+// Pos returns the position of the identifier the diagnostic is about
+func (d Diagnostic) Pos() token.Pos { return d.Token.Pos }
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Pos(), d.Severity, d.Code, d.Message)
+}
+
+type declaration struct {
+	name     *ast.Identifier
+	constant bool
+	slot     int
+	used     bool
+}
+
+// scope is the table of the names declared in a program or block
+type scope struct {
+	declarations map[string]*declaration
+	order        []*declaration
+	// pending holds the names declared anywhere in the scope, so a use
+	// before the declaration can be told apart from an undefined name
+	pending map[string]bool
+}
+
+// Resolver binds every identifier of a program to its declaration.
+// Its top level scope persists between calls to Resolve, so a session
+// such as the REPL can resolve one input at a time
+type Resolver struct {
+	scopes      []*scope
+	diagnostics []Diagnostic
+}
+
+// New returns a resolver with an empty top level scope
+func New() *Resolver {
+	r := &Resolver{}
+	r.openScope(nil)
+	return r
+}
+
+// Resolve resolves program in a fresh resolver and returns its diagnostics
+func Resolve(program *ast.Program) []Diagnostic {
+	return New().Resolve(program)
+}
+
+// Declare adds names to the top level scope as if they were already declared and used,
+// e.g. for built-ins or bindings that live in the evaluator environment, as constants if
+// constant is set. They take the next slots in order, so names from an environment go in
+// the order of its slots
+func (r *Resolver) Declare(constant bool, names ...string) {
+	global := r.scopes[0]
+	for _, name := range names {
+		if _, ok := global.declarations[name]; ok {
+			continue
+		}
+		decl := &declaration{
+			name:     &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name},
+			constant: constant,
+			slot:     len(global.order),
+			used:     true,
+		}
+		global.declarations[name] = decl
+		global.order = append(global.order, decl)
+	}
+}
+
+// Resolve annotates the identifiers of program with their scope depth and slot
+// and returns the diagnostics found, in source order
+func (r *Resolver) Resolve(program *ast.Program) []Diagnostic {
+	r.diagnostics = nil
+	global := r.scopes[0]
+	global.pending = declaredNames(program.Statements)
+	start := len(global.order)
+
+	r.resolveStatements(program.Statements)
+
+	global.pending = nil
+	r.reportUnused(global.order[start:])
+	sort.SliceStable(r.diagnostics, func(i, j int) bool {
+		return r.diagnostics[i].Pos().Offset < r.diagnostics[j].Pos().Offset
+	})
+	return r.diagnostics
+}
+
+func (r *Resolver) resolveStatements(statements []ast.Statement) {
+	for _, s := range statements {
+		r.resolve(s)
+	}
+}
+
+func (r *Resolver) resolve(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.ExpressionStatement:
+		r.resolveExpression(node.Expression)
+	case *ast.VarStatement:
+		r.resolveExpression(node.Value)
+		r.declare(node.Name, node.Constant)
+	case *ast.ReturnStatement:
+		r.resolveExpression(node.ReturnValue)
+	case *ast.BlockStatement:
+		r.openScope(node.Statements)
+		r.resolveStatements(node.Statements)
+		r.closeScope()
+	case *ast.Identifier:
+		r.lookup(node)
+	case *ast.PrefixExpression:
+		r.resolveExpression(node.Right)
+	case *ast.InfixExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Right)
+	case *ast.CallExpression:
+		r.resolveExpression(node.Function)
+		for _, arg := range node.Arguments {
+			r.resolveExpression(arg)
+		}
+	case *ast.IfExpression:
+		r.resolveExpression(node.Condition)
+		r.resolve(node.Consequence)
+		if node.Alternative != nil {
+			r.resolve(node.Alternative)
+		}
+	}
+}
+
+// resolveExpression resolves exp, skipping the nil expressions left by parse errors
+func (r *Resolver) resolveExpression(exp ast.Expression) {
+	if exp != nil {
+		r.resolve(exp)
+	}
+}
+
+func (r *Resolver) openScope(statements []ast.Statement) {
+	r.scopes = append(r.scopes, &scope{
+		declarations: make(map[string]*declaration),
+		pending:      declaredNames(statements),
+	})
+}
+
+func (r *Resolver) closeScope() {
+	s := r.scopes[len(r.scopes)-1]
+	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.reportUnused(s.order)
+}
+
+// declare adds name to the innermost scope, reporting it if the scope already has it
+func (r *Resolver) declare(name *ast.Identifier, constant bool) {
+	s := r.scopes[len(r.scopes)-1]
+	if existing, ok := s.declarations[name.Value]; ok {
+		if existing.constant {
+			r.report(severity.Error, DuplicateDeclaration, name, "cannot reassign constant %s", name.Value)
+		} else {
+			r.report(severity.Error, DuplicateDeclaration, name, "%s redeclared in this scope", name.Value)
+		}
+		return
+	}
+	decl := &declaration{name: name, constant: constant, slot: len(s.order)}
+	s.declarations[name.Value] = decl
+	s.order = append(s.order, decl)
+}
+
+// lookup binds ident to the closest declaration of its name
+func (r *Resolver) lookup(ident *ast.Identifier) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if decl, ok := r.scopes[i].declarations[ident.Value]; ok {
+			decl.used = true
+			ident.Resolution = &ast.Resolution{Depth: len(r.scopes) - 1 - i, Slot: decl.slot}
+			return
+		}
+	}
+	for _, s := range r.scopes {
+		if s.pending[ident.Value] {
+			r.report(severity.Error, UsedBeforeDeclaration, ident, "%s used before declaration", ident.Value)
+			return
+		}
+	}
+	r.report(severity.Error, UndefinedName, ident, "undefined: %s", ident.Value)
+}
+
+func (r *Resolver) reportUnused(declarations []*declaration) {
+	for _, decl := range declarations {
+		if !decl.used {
+			r.report(severity.Warning, UnusedVariable, decl.name, "%s declared and not used", decl.name.Value)
+		}
+	}
+}
+
+func (r *Resolver) report(level severity.Level, code string, ident *ast.Identifier, format string, a ...interface{}) {
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		Severity: level,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Token:    ident.Token,
+	})
+}
+
+// HELPERS
+
+// declaredNames returns the names declared directly in statements
+func declaredNames(statements []ast.Statement) map[string]bool {
+	names := make(map[string]bool)
+	for _, s := range statements {
+		if vs, ok := s.(*ast.VarStatement); ok && vs.Name != nil {
+			names[vs.Name.Value] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,142 @@
+package resolver
+
+import (
+	"blank/ast"
+	"blank/lexer"
+	"blank/parser"
+	"testing"
+)
+
+func TestResolveDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"var x = 1; x;", nil},
+		{"y;", []string{"1:1: error[R001]: undefined: y"}},
+		{"x; var x = 1; x;", []string{"1:1: error[R002]: x used before declaration"}},
+		{"var x = x;", []string{
+			"1:5: warning[R004]: x declared and not used",
+			"1:9: error[R002]: x used before declaration",
+		}},
+		{"var x = 1; var x = 2; x;", []string{"1:16: error[R003]: x redeclared in this scope"}},
+		{"const x = 1; if (true) { const x = 2; x; } x;", nil},
+		{"var x = 1;", []string{"1:5: warning[R004]: x declared and not used"}},
+		{"if (true) { var y = 1; } y;", []string{
+			"1:17: warning[R004]: y declared and not used",
+			"1:26: error[R001]: undefined: y",
+		}},
+		{"var x = 1; if (x > 0) { x; } else { return -x; }", nil},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		diagnostics := Resolve(program)
+		if len(diagnostics) != len(tt.expected) {
+			t.Errorf("input %q: expected %d diagnostics, got=%d (%v)",
+				tt.input, len(tt.expected), len(diagnostics), diagnostics)
+			continue
+		}
+		for i, d := range diagnostics {
+			if d.String() != tt.expected[i] {
+				t.Errorf("input %q: diagnostics[%d] wrong. expected=%q, got=%q",
+					tt.input, i, tt.expected[i], d.String())
+			}
+		}
+	}
+}
+
+func TestResolveAnnotatesIdentifiers(t *testing.T) {
+	input := `
+		var a = 1;
+		var b = 2;
+		if (true) {
+			var c = b;
+			if (true) { a + c; }
+		}
+	`
+	program := parse(t, input)
+	if diagnostics := Resolve(program); len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+
+	outer := program.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.IfExpression).Consequence
+	b := outer.Statements[0].(*ast.VarStatement).Value.(*ast.Identifier)
+	inner := outer.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.IfExpression).Consequence
+	sum := inner.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.InfixExpression)
+
+	tests := []struct {
+		ident         *ast.Identifier
+		expectedDepth int
+		expectedSlot  int
+	}{
+		{b, 1, 1},
+		{sum.Left.(*ast.Identifier), 2, 0},
+		{sum.Right.(*ast.Identifier), 1, 0},
+	}
+
+	for _, tt := range tests {
+		if tt.ident.Resolution == nil {
+			t.Errorf("%s was not resolved", tt.ident.Value)
+			continue
+		}
+		if tt.ident.Resolution.Depth != tt.expectedDepth {
+			t.Errorf("%s depth not %d. got=%d", tt.ident.Value, tt.expectedDepth, tt.ident.Resolution.Depth)
+		}
+		if tt.ident.Resolution.Slot != tt.expectedSlot {
+			t.Errorf("%s slot not %d. got=%d", tt.ident.Value, tt.expectedSlot, tt.ident.Resolution.Slot)
+		}
+	}
+}
+
+func TestResolverKeepsTopLevelScope(t *testing.T) {
+	r := New()
+	r.Declare(true, "builtin")
+
+	inputs := []struct {
+		input    string
+		expected int
+	}{
+		{"var x = builtin;", 1}, // x is not used yet
+		{"x;", 0},
+		{"var x = 2;", 1},
+	}
+
+	for _, tt := range inputs {
+		diagnostics := r.Resolve(parse(t, tt.input))
+		if len(diagnostics) != tt.expected {
+			t.Errorf("input %q: expected %d diagnostics, got=%d (%v)",
+				tt.input, tt.expected, len(diagnostics), diagnostics)
+		}
+	}
+}
+
+func TestDeclareConstants(t *testing.T) {
+	r := New()
+	r.Declare(true, "len")
+	r.Declare(false, "x")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var len = 1; len;", "1:5: error[R003]: cannot reassign constant len"},
+		{"var x = 1; x;", "1:5: error[R003]: x redeclared in this scope"},
+	}
+
+	for _, tt := range tests {
+		diagnostics := r.Resolve(parse(t, tt.input))
+		if len(diagnostics) != 1 || diagnostics[0].String() != tt.expected {
+			t.Errorf("input %q: wrong diagnostics. expected=%q, got=%v", tt.input, tt.expected, diagnostics)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
@@ -0,0 +1,18 @@
+// Package severity defines how serious a problem reported about a program is,
+// shared by the parser, the resolver and the diagnostics rendered from them.
+package severity
+
+// Level is the severity of a problem. Errors stop the program from running, warnings do not
+type Level int
+
+const (
+	Error Level = iota
+	Warning
+)
+
+func (l Level) String() string {
+	if l == Warning {
+		return "warning"
+	}
+	return "error"
+}
@@ -1,19 +1,50 @@
 package token
 
+import (
+	"fmt"
+	"sort"
+)
+
 type TokenType string
 
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type    TokenType `json:"type"`
+	Literal string    `json:"literal"`
+	Pos     Pos       `json:"pos"` // position of the first character of the token
+	End     Pos       `json:"end"` // position immediately after the token
+}
+
+// Pos is a location in the source code. The zero value is an invalid position
+type Pos struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line"`   // starting at 1
+	Column   int    `json:"column"` // starting at 1
+	Offset   int    `json:"offset"` // byte offset, starting at 0
+}
+
+// IsValid reports whether the position has been set
+func (p Pos) IsValid() bool { return p.Line > 0 }
+
+// String returns the position as "file:line:column", or "line:column" when there is no filename
+func (p Pos) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	if p.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT"
 
 	// Identifiers + literals
-	IDENT = "IDENT"
-	INT   = "INT"
+	IDENT  = "IDENT"
+	INT    = "INT"
+	STRING = "STRING"
 
 	// Operators
 	ASSIGN   = "="
@@ -40,6 +71,7 @@ const (
 	// Keywords
 	FUNCTION = "FUNCTION"
 	VAR      = "VAR"
+	CONST    = "CONST"
 	RETURN   = "RETURN"
 	BREAK    = "BREAK"
 	IF       = "IF"
@@ -53,6 +85,7 @@ const (
 var keywords = map[string]TokenType{
 	"func":     FUNCTION,
 	"var":      VAR,
+	"const":    CONST,
 	"return":   RETURN,
 	"break":    BREAK,
 	"if":       IF,
@@ -63,6 +96,16 @@ var keywords = map[string]TokenType{
 	"false":    FALSE,
 }
 
+// Keywords returns the reserved words of the language, sorted
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
 func LookupIdent(ident string) TokenType {
 	if tok, exists := keywords[ident]; exists {
 		return tok